@@ -0,0 +1,163 @@
+/*
+Copyright 2023 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"context"
+
+	"github.com/go-piv/piv-go/piv"
+	"github.com/gravitational/trace"
+)
+
+// minFirmwareVersionForMoveOrDelete is the first YubiKey firmware version that supports
+// moving or deleting an individual PIV key without resetting the whole applet.
+var minFirmwareVersionForMoveOrDelete = piv.Version{Major: 5, Minor: 7, Patch: 0}
+
+// moveKey moves the private key in src to dst with the card's move-key command, gated on
+// firmware support. It does not re-issue the slot marker certificate on dst, and it does not
+// check afterwards that src is empty.
+func (y *yubiKey) moveKey(ctx context.Context, src, dst piv.Slot, managementKey []byte) error {
+	yk, err := y.acquire()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer y.release()
+
+	if versionLess(yk.Version(), minFirmwareVersionForMoveOrDelete) {
+		return trace.NotImplemented("moving a YubiKey PIV key requires firmware %d.%d.%d or later",
+			minFirmwareVersionForMoveOrDelete.Major, minFirmwareVersionForMoveOrDelete.Minor, minFirmwareVersionForMoveOrDelete.Patch)
+	}
+
+	if err := yk.MoveKey(managementKey, src, dst); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+// deleteKey deletes the private key and certificate in the given slot, gated on firmware
+// support. On older firmware, the slot can only be cleared by resetting the whole PIV applet.
+func (y *yubiKey) deleteKey(slot piv.Slot, managementKey []byte) error {
+	yk, err := y.acquire()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer y.release()
+
+	if versionLess(yk.Version(), minFirmwareVersionForMoveOrDelete) {
+		return trace.NotImplemented("deleting a YubiKey PIV key requires firmware %d.%d.%d or later",
+			minFirmwareVersionForMoveOrDelete.Major, minFirmwareVersionForMoveOrDelete.Minor, minFirmwareVersionForMoveOrDelete.Patch)
+	}
+
+	if err := yk.DeleteKey(managementKey, slot); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+// PIVSlotInfo describes the contents of a single PIV slot for `tsh piv list`.
+type PIVSlotInfo struct {
+	// Slot is the PIV slot key, e.g. 0x9a.
+	Slot piv.Slot
+	// TeleportManaged is true if the slot holds a Teleport self-signed marker certificate.
+	TeleportManaged bool
+	// Policy is the private key policy recorded on the marker certificate, if any.
+	Policy PrivateKeyPolicy
+}
+
+// pivSlotsToAudit are the slots checked by ListPIVSlots: the four standard slots plus
+// the 20 retired key management slots.
+func pivSlotsToAudit() []piv.Slot {
+	slots := []piv.Slot{pivSlotNoTouch, pivSlotWithTouch, pivSlotWithPIN, pivSlotWithTouchAndPIN}
+	for key := uint32(0x82); key <= 0x95; key++ {
+		if slot, ok := piv.RetiredKeyManagementSlot(key); ok {
+			slots = append(slots, slot)
+		}
+	}
+	return slots
+}
+
+// ListPIVSlots enumerates all standard and retired PIV slots on the given YubiKey serial
+// (0 selects the first connected YubiKey) and reports which ones hold a Teleport marker,
+// so operators can audit and clean up stale keys left behind by upgrades.
+func ListPIVSlots(ctx context.Context, serialNumber uint32) ([]PIVSlotInfo, error) {
+	y, err := findYubiKey(ctx, serialNumber)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	yk, err := y.acquire()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer y.release()
+
+	var infos []PIVSlotInfo
+	for _, slot := range pivSlotsToAudit() {
+		info := PIVSlotInfo{Slot: slot}
+		if cert, err := yk.Certificate(slot); err == nil && cert != nil &&
+			len(cert.Subject.Organization) > 0 && cert.Subject.Organization[0] == certOrgName {
+			info.TeleportManaged = true
+			info.Policy = privateKeyPolicyFromCertificate(cert)
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// ResetPIVSlot deletes the Teleport key and marker certificate from the given slot on the
+// given YubiKey, so a stale or mismatched private key policy can be reprovisioned from scratch.
+func ResetPIVSlot(ctx context.Context, serialNumber uint32, slotKey uint32, managementKey []byte) error {
+	y, err := findYubiKey(ctx, serialNumber)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	slot, err := parsePIVSlot(slotKey)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	if managementKey == nil {
+		managementKey = piv.DefaultManagementKey
+	}
+
+	return trace.Wrap(y.deleteKey(slot, managementKey))
+}
+
+// MovePIVSlot moves the Teleport private key from src to dst on the given YubiKey through
+// moveKey; the marker certificate is not re-issued on dst.
+func MovePIVSlot(ctx context.Context, serialNumber uint32, srcSlotKey, dstSlotKey uint32, managementKey []byte) error {
+	y, err := findYubiKey(ctx, serialNumber)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	src, err := parsePIVSlot(srcSlotKey)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	dst, err := parsePIVSlot(dstSlotKey)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	if managementKey == nil {
+		managementKey = piv.DefaultManagementKey
+	}
+
+	return trace.Wrap(y.moveKey(ctx, src, dst, managementKey))
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-piv/piv-go/piv"
+	"github.com/gravitational/trace"
+)
+
+// yubiKeyURIScheme is the URI scheme used to configure the PIV slot, algorithm,
+// and management key used by a YubiKey private key, following the convention
+// used by smallstep's YubiKey KMS (e.g. `yubikey:?slot=9a&serial=1234567`).
+const yubiKeyURIScheme = "yubikey"
+
+// YubiKeyConfig configures how a YubiKey PIV private key is generated or retrieved.
+// Fields left unset fall back to the defaults used for the requested PrivateKeyPolicy.
+type YubiKeyConfig struct {
+	// Slot is the PIV slot to generate or retrieve the private key from.
+	Slot *piv.Slot
+	// Serial is the serial number of the YubiKey to use. If unset, the first
+	// connected YubiKey is used.
+	Serial uint32
+	// Algorithm is the key algorithm to generate. If unset, PrivateKeyPolicy
+	// defaults (currently EC256) are used.
+	Algorithm *piv.Algorithm
+	// ManagementKey is used to generate keys and certificates on the slot. If
+	// unset, piv.DefaultManagementKey is used.
+	ManagementKey []byte
+	// PINPolicy, if set, overrides the PIN policy implied by the PrivateKeyPolicy.
+	PINPolicy *piv.PINPolicy
+	// TouchPolicy, if set, overrides the touch policy implied by the PrivateKeyPolicy.
+	TouchPolicy *piv.TouchPolicy
+}
+
+// ParseYubiKeyURI parses a `yubikey://` URI of the form
+// `yubikey:?slot=9a&serial=1234567&algorithm=EC256&management-key=<hex>&pin-policy=once&touch-policy=cached`
+// into a YubiKeyConfig.
+func ParseYubiKeyURI(uri string) (*YubiKeyConfig, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if u.Scheme != yubiKeyURIScheme {
+		return nil, trace.BadParameter("unsupported private key URI scheme %q, expected %q", u.Scheme, yubiKeyURIScheme)
+	}
+
+	query := u.Query()
+	cfg := &YubiKeyConfig{}
+
+	if slotStr := query.Get("slot"); slotStr != "" {
+		slotKey, err := strconv.ParseUint(slotStr, 16, 32)
+		if err != nil {
+			return nil, trace.BadParameter("invalid slot %q: %v", slotStr, err)
+		}
+		slot, err := parsePIVSlot(uint32(slotKey))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		cfg.Slot = &slot
+	}
+
+	if serialStr := query.Get("serial"); serialStr != "" {
+		serial, err := strconv.ParseUint(serialStr, 10, 32)
+		if err != nil {
+			return nil, trace.BadParameter("invalid serial %q: %v", serialStr, err)
+		}
+		cfg.Serial = uint32(serial)
+	}
+
+	if algStr := query.Get("algorithm"); algStr != "" {
+		alg, err := parsePIVAlgorithm(algStr)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		cfg.Algorithm = &alg
+	}
+
+	if mgmtKeyStr := query.Get("management-key"); mgmtKeyStr != "" {
+		mgmtKey, err := hex.DecodeString(mgmtKeyStr)
+		if err != nil {
+			return nil, trace.BadParameter("invalid management-key, expected hex encoding: %v", err)
+		}
+		cfg.ManagementKey = mgmtKey
+	}
+
+	if pinPolicyStr := query.Get("pin-policy"); pinPolicyStr != "" {
+		pinPolicy, err := parsePIVPINPolicy(pinPolicyStr)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		cfg.PINPolicy = &pinPolicy
+	}
+
+	if touchPolicyStr := query.Get("touch-policy"); touchPolicyStr != "" {
+		touchPolicy, err := parsePIVTouchPolicy(touchPolicyStr)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		cfg.TouchPolicy = &touchPolicy
+	}
+
+	return cfg, nil
+}
+
+func parsePIVAlgorithm(algorithm string) (piv.Algorithm, error) {
+	switch strings.ToUpper(algorithm) {
+	case "EC256":
+		return piv.AlgorithmEC256, nil
+	case "RSA1024":
+		return piv.AlgorithmRSA1024, nil
+	case "RSA2048":
+		return piv.AlgorithmRSA2048, nil
+	case "RSA3072":
+		return piv.AlgorithmRSA3072, nil
+	case "RSA4096":
+		return piv.AlgorithmRSA4096, nil
+	case "ED25519":
+		return piv.AlgorithmEd25519, nil
+	case "X25519":
+		return piv.AlgorithmX25519, nil
+	default:
+		return 0, trace.BadParameter("unsupported PIV algorithm %q", algorithm)
+	}
+}
+
+func parsePIVPINPolicy(policy string) (piv.PINPolicy, error) {
+	switch strings.ToLower(policy) {
+	case "never":
+		return piv.PINPolicyNever, nil
+	case "once":
+		return piv.PINPolicyOnce, nil
+	case "always":
+		return piv.PINPolicyAlways, nil
+	default:
+		return 0, trace.BadParameter("unsupported PIV PIN policy %q", policy)
+	}
+}
+
+func parsePIVTouchPolicy(policy string) (piv.TouchPolicy, error) {
+	switch strings.ToLower(policy) {
+	case "never":
+		return piv.TouchPolicyNever, nil
+	case "always":
+		return piv.TouchPolicyAlways, nil
+	case "cached":
+		return piv.TouchPolicyCached, nil
+	default:
+		return 0, trace.BadParameter("unsupported PIV touch policy %q", policy)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2023 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+// PrivateKeyPolicy is a requirement for client private key storage.
+type PrivateKeyPolicy string
+
+const (
+	// PrivateKeyPolicyNone means that the client can use an in-memory or on-disk
+	// private key normally.
+	PrivateKeyPolicyNone PrivateKeyPolicy = "none"
+	// PrivateKeyPolicyHardwareKey means that the client must use a valid
+	// hardware private key, such as a PIV slot on a YubiKey.
+	PrivateKeyPolicyHardwareKey PrivateKeyPolicy = "hardware_key"
+	// PrivateKeyPolicyHardwareKeyTouch means that the client must use a valid
+	// hardware private key that requires touch to be used.
+	PrivateKeyPolicyHardwareKeyTouch PrivateKeyPolicy = "hardware_key_touch"
+	// PrivateKeyPolicyHardwareKeyPIN means that the client must use a valid
+	// hardware private key that requires PIN to be used.
+	PrivateKeyPolicyHardwareKeyPIN PrivateKeyPolicy = "hardware_key_pin"
+	// PrivateKeyPolicyHardwareKeyTouchAndPIN means that the client must use a
+	// valid hardware private key that requires both touch and PIN to be used.
+	PrivateKeyPolicyHardwareKeyTouchAndPIN PrivateKeyPolicy = "hardware_key_touch_and_pin"
+)
+
+// IsSatisfiedBy returns true if this policy's requirements are satisfied by the given policy.
+func (p PrivateKeyPolicy) IsSatisfiedBy(policy PrivateKeyPolicy) bool {
+	switch p {
+	case PrivateKeyPolicyNone:
+		return true
+	case PrivateKeyPolicyHardwareKey:
+		switch policy {
+		case PrivateKeyPolicyHardwareKey, PrivateKeyPolicyHardwareKeyTouch,
+			PrivateKeyPolicyHardwareKeyPIN, PrivateKeyPolicyHardwareKeyTouchAndPIN:
+			return true
+		}
+		return false
+	case PrivateKeyPolicyHardwareKeyTouch:
+		return policy == PrivateKeyPolicyHardwareKeyTouch || policy == PrivateKeyPolicyHardwareKeyTouchAndPIN
+	case PrivateKeyPolicyHardwareKeyPIN:
+		return policy == PrivateKeyPolicyHardwareKeyPIN || policy == PrivateKeyPolicyHardwareKeyTouchAndPIN
+	case PrivateKeyPolicyHardwareKeyTouchAndPIN:
+		return policy == PrivateKeyPolicyHardwareKeyTouchAndPIN
+	}
+	return false
+}
+
+// requiresTouch returns true if this policy requires touch.
+func (p PrivateKeyPolicy) requiresTouch() bool {
+	return p == PrivateKeyPolicyHardwareKeyTouch || p == PrivateKeyPolicyHardwareKeyTouchAndPIN
+}
+
+// requiresPIN returns true if this policy requires a PIN.
+func (p PrivateKeyPolicy) requiresPIN() bool {
+	return p == PrivateKeyPolicyHardwareKeyPIN || p == PrivateKeyPolicyHardwareKeyTouchAndPIN
+}
@@ -17,9 +17,13 @@ limitations under the License.
 package keys
 
 import (
+	"bufio"
 	"context"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/json"
@@ -29,6 +33,7 @@ import (
 	"math/big"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-piv/piv-go/piv"
@@ -50,31 +55,111 @@ var (
 	// We use slot 9c for Teleport Clients which require `private_key_policy: hardware_key_touch`.
 	// Private keys generated on this slot will use TouchPolicy=Cached.
 	pivSlotWithTouch = piv.SlotSignature
+	// We use slot 9d for Teleport Clients which require `private_key_policy: hardware_key_pin`.
+	// Private keys generated on this slot will use PINPolicy=Once, relying on the YubiKey's
+	// own PIN caching for subsequent operations.
+	pivSlotWithPIN = piv.SlotKeyManagement
+	// We use slot 9e for Teleport Clients which require
+	// `private_key_policy: hardware_key_touch_and_pin`.
+	pivSlotWithTouchAndPIN = piv.SlotCardAuthentication
 )
 
+// PINPrompt is a callback used to collect a YubiKey PIV PIN from the user.
+type PINPrompt func() (string, error)
+
+// pinPrompt is the PINPrompt used by newly generated YubiKeyPrivateKeys.
+// It can be overridden by clients (tsh, Teleport Connect) to provide a
+// PIN prompt appropriate for the surrounding UI.
+var pinPrompt PINPrompt = stdinPINPrompt
+
+func stdinPINPrompt() (string, error) {
+	fmt.Fprint(os.Stderr, "Enter your YubiKey PIV PIN: ")
+	pin, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return strings.TrimSpace(pin), nil
+}
+
+// SetYubiKeyPINPrompt overrides the prompt used to collect a YubiKey PIV PIN.
+func SetYubiKeyPINPrompt(prompt PINPrompt) {
+	pinPrompt = prompt
+}
+
+// pivSlotForPolicy returns the PIV slot and touch/PIN policies that should be
+// used to generate or retrieve a private key satisfying the given policy.
+func pivSlotForPolicy(policy PrivateKeyPolicy) (slot piv.Slot, touchPolicy piv.TouchPolicy, pinPolicy piv.PINPolicy) {
+	switch {
+	case policy.requiresTouch() && policy.requiresPIN():
+		return pivSlotWithTouchAndPIN, piv.TouchPolicyCached, piv.PINPolicyOnce
+	case policy.requiresPIN():
+		return pivSlotWithPIN, piv.TouchPolicyNever, piv.PINPolicyOnce
+	case policy.requiresTouch():
+		return pivSlotWithTouch, piv.TouchPolicyCached, piv.PINPolicyNever
+	default:
+		return pivSlotNoTouch, piv.TouchPolicyNever, piv.PINPolicyNever
+	}
+}
+
 // getOrGenerateYubiKeyPrivateKey connects to a connected yubiKey and gets a private key
-// matching the given touch requirement. This private key will either be newly generated
-// or previously generated by a Teleport client and reused.
-func getOrGenerateYubiKeyPrivateKey(ctx context.Context, touchRequired bool) (*PrivateKey, error) {
-	// Use the first yubiKey we find.
-	y, err := findYubiKey(ctx, 0)
+// satisfying the given private key policy. This private key will either be newly generated
+// or previously generated by a Teleport client and reused. cfg may be nil, in which case
+// defaults are derived entirely from policy.
+func getOrGenerateYubiKeyPrivateKey(ctx context.Context, policy PrivateKeyPolicy, cfg *YubiKeyConfig) (*PrivateKey, error) {
+	if cfg == nil {
+		cfg = &YubiKeyConfig{}
+	}
+
+	y, err := findYubiKey(ctx, cfg.Serial)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
-	// Get the correct PIV slot and Touch policy for the given touch requirement.
-	pivSlot := pivSlotNoTouch
-	touchPolicy := piv.TouchPolicyNever
-	if touchRequired {
-		pivSlot = pivSlotWithTouch
-		touchPolicy = piv.TouchPolicyCached
+	pivSlot, touchPolicy, pinPolicy := pivSlotForPolicy(policy)
+	if cfg.Slot != nil {
+		pivSlot = *cfg.Slot
+	}
+	if cfg.TouchPolicy != nil {
+		touchPolicy = *cfg.TouchPolicy
+	}
+	if cfg.PINPolicy != nil {
+		pinPolicy = *cfg.PINPolicy
+	}
+
+	// Sign() decides whether to prompt for a PIN/touch purely from policy.requiresPIN()/
+	// requiresTouch(), which reflects the requested PrivateKeyPolicy, not whatever
+	// cfg.PINPolicy/TouchPolicy actually provisions the slot with. An override that disagrees
+	// with policy would provision a slot Sign() doesn't know to prompt for (or a slot Sign()
+	// prompts for needlessly), so refuse it up front instead of generating a key Sign() can't
+	// use correctly.
+	if (pinPolicy != piv.PINPolicyNever) != policy.requiresPIN() {
+		return nil, trace.BadParameter("YubiKey PIN policy %v is inconsistent with private key policy %q", pinPolicy, policy)
+	}
+	if (touchPolicy != piv.TouchPolicyNever) != policy.requiresTouch() {
+		return nil, trace.BadParameter("YubiKey touch policy %v is inconsistent with private key policy %q", touchPolicy, policy)
+	}
+
+	algorithm := piv.AlgorithmEC256
+	if cfg.Algorithm != nil {
+		algorithm = *cfg.Algorithm
+	}
+
+	managementKey := piv.DefaultManagementKey
+	if cfg.ManagementKey != nil {
+		managementKey = cfg.ManagementKey
 	}
 
-	// First, check if there is already a private key set up by a Teleport Client.
-	priv, err := y.getPrivateKey(ctx, pivSlot)
+	// First, check if there is already a private key set up by a Teleport Client. Only a
+	// NotFound error (slot truly empty) is safe to paper over by generating a new key; a
+	// policy mismatch or a slot already occupied by a foreign cert must be returned to the
+	// caller as-is instead of silently overwriting whatever's already on the slot.
+	priv, err := y.getPrivateKey(ctx, pivSlot, policy)
 	if err != nil {
+		if !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
 		// Generate a new private key on the PIV slot.
-		if priv, err = y.generatePrivateKey(ctx, pivSlot, touchPolicy); err != nil {
+		if priv, err = y.generatePrivateKey(ctx, pivSlot, touchPolicy, pinPolicy, algorithm, managementKey, policy); err != nil {
 			return nil, trace.Wrap(err)
 		}
 	}
@@ -94,6 +179,15 @@ type YubiKeyPrivateKey struct {
 	*yubiKey
 	pivSlot piv.Slot
 	pub     crypto.PublicKey
+	// policy is the private key policy this slot was provisioned with.
+	policy PrivateKeyPolicy
+
+	pinMu sync.Mutex
+	// pinVerified is set once the PIN has been supplied to the slot for this
+	// YubiKeyPrivateKey. Slots provisioned with PINPolicyOnce only need the PIN on the first
+	// operation; the YubiKey's PIV applet caches the verification for the rest of the card's
+	// session, so later Sign calls don't prompt again.
+	pinVerified bool
 }
 
 // yubiKeyPrivateKeyData is marshalable data used to retrieve a specific yubiKey PIV private key.
@@ -102,18 +196,19 @@ type yubiKeyPrivateKeyData struct {
 	SlotKey      uint32 `json:"slot_key"`
 }
 
-func newYubiKeyPrivateKey(ctx context.Context, y *yubiKey, slot piv.Slot, pub crypto.PublicKey) (*YubiKeyPrivateKey, error) {
+func newYubiKeyPrivateKey(ctx context.Context, y *yubiKey, slot piv.Slot, pub crypto.PublicKey, policy PrivateKeyPolicy) (*YubiKeyPrivateKey, error) {
 	return &YubiKeyPrivateKey{
 		yubiKey: y,
 		pivSlot: slot,
 		pub:     pub,
+		policy:  policy,
 	}, nil
 }
 
 func parseYubiKeyPrivateKeyData(keyDataBytes []byte) (*YubiKeyPrivateKey, error) {
-	// TODO (Joerger): rather than requiring a context be passed here, we should
-	// pre-load the yubikey PIV connection to avoid retry/context logic occurring
-	// at spontaneous points in the code (anywhere a private key is used).
+	// The yubiKey connection itself is now long-lived and shared per PIV card reader (see
+	// the yubiKey registry below), so the retry/backoff cost of opening a PIV connection
+	// is only ever paid once per card, not on every private key operation.
 	ctx := context.TODO()
 
 	var keyData yubiKeyPrivateKeyData
@@ -131,7 +226,9 @@ func parseYubiKeyPrivateKeyData(keyDataBytes []byte) (*YubiKeyPrivateKey, error)
 		return nil, trace.Wrap(err)
 	}
 
-	priv, err := y.getPrivateKey(ctx, pivSlot)
+	// The policy recorded on the slot's marker certificate is authoritative;
+	// we don't know what the caller expects ahead of time.
+	priv, err := y.getPrivateKey(ctx, pivSlot, PrivateKeyPolicyNone)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -146,18 +243,42 @@ func (y *YubiKeyPrivateKey) Public() crypto.PublicKey {
 
 // Sign implements crypto.Signer.
 func (y *YubiKeyPrivateKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
-	yk, err := y.open()
+	yk, err := y.acquire()
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	defer yk.Close()
+	defer y.release()
+
+	keyAuth := piv.KeyAuth{}
+	if y.policy.requiresPIN() {
+		y.pinMu.Lock()
+		alreadyVerified := y.pinVerified
+		y.pinMu.Unlock()
+
+		if !alreadyVerified {
+			pin, err := pinPrompt()
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			keyAuth.PIN = pin
+		}
+	}
 
-	privateKey, err := yk.PrivateKey(y.pivSlot, y.pub, piv.KeyAuth{})
+	privateKey, err := yk.PrivateKey(y.pivSlot, y.pub, keyAuth)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
-	if y.pivSlot == pivSlotWithTouch {
+	if y.policy.requiresPIN() {
+		y.pinMu.Lock()
+		y.pinVerified = true
+		y.pinMu.Unlock()
+	}
+
+	// If both touch and PIN are required, the PIN prompt above has already
+	// blocked until the user typed their PIN, so don't start the delayed
+	// touch prompt until that's done.
+	if y.policy.requiresTouch() {
 		cancelTouchPrompt := delayedTouchPrompt(signTouchPromptDelay)
 		defer cancelTouchPrompt()
 	}
@@ -187,11 +308,11 @@ func (y *YubiKeyPrivateKey) keyPEM() ([]byte, error) {
 
 // GetAttestationStatement returns an AttestationStatement for this YubiKeyPrivateKey.
 func (y *YubiKeyPrivateKey) GetAttestationStatement() (*attestation.AttestationStatement, error) {
-	yk, err := y.open()
+	yk, err := y.acquire()
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	defer yk.Close()
+	defer y.release()
 
 	slotCert, err := yk.Attest(y.pivSlot)
 	if err != nil {
@@ -220,57 +341,197 @@ func (k *YubiKeyPrivateKey) GetPrivateKeyPolicy() PrivateKeyPolicy {
 		return PrivateKeyPolicyHardwareKey
 	case pivSlotWithTouch:
 		return PrivateKeyPolicyHardwareKeyTouch
+	case pivSlotWithPIN:
+		return PrivateKeyPolicyHardwareKeyPIN
+	case pivSlotWithTouchAndPIN:
+		return PrivateKeyPolicyHardwareKeyTouchAndPIN
 	default:
 		return PrivateKeyPolicyNone
 	}
 }
 
-// yubiKey is a specific yubiKey PIV card.
+// yubiKey is a specific yubiKey PIV card. A single yubiKey holds one long-lived,
+// reference-counted PIV connection shared by every YubiKeyPrivateKey derived from it,
+// since piv-go only permits one connection to a card at a time and re-opening one for
+// every Sign/GetAttestationStatement call is a significant per-operation cost under
+// rapid-fire signing (tsh proxy loops, Teleport Connect).
 type yubiKey struct {
 	// card is a reader name used to find and connect to this yubiKey.
 	// This value may change between OS's, or with other system changes.
 	card string
-	// serialNumber is the yubiKey's 8 digit serial number.
+
+	mu sync.Mutex
+	// serialNumber is the yubiKey's 8 digit serial number. Zero until the
+	// connection has been opened at least once.
 	serialNumber uint32
+	// handle is the shared PIV connection, or nil if it hasn't been opened
+	// yet or has been closed by Shutdown.
+	handle *piv.YubiKey
+	// refCount is the number of in-flight operations using handle.
+	refCount int
 }
 
-func newYubiKey(ctx context.Context, card string) (*yubiKey, error) {
+// yubiKeyRegistry caches yubiKey connections by PIV card reader name, so that every
+// YubiKeyPrivateKey derived from the same card shares one underlying PIV connection.
+var (
+	yubiKeyRegistryMu sync.Mutex
+	yubiKeyRegistry   = make(map[string]*yubiKey)
+)
+
+func cachedYubiKey(card string) *yubiKey {
+	yubiKeyRegistryMu.Lock()
+	defer yubiKeyRegistryMu.Unlock()
+	if y, ok := yubiKeyRegistry[card]; ok {
+		return y
+	}
 	y := &yubiKey{card: card}
+	yubiKeyRegistry[card] = y
+	return y
+}
 
-	yk, err := y.open()
-	if err != nil {
-		return nil, trace.Wrap(err)
+// newYubiKey returns the cached yubiKey for the given card, connecting to and
+// reading its serial number directly via yk.Serial() if this is the first use.
+// This avoids attestation, which fails on older firmware and on slots that don't
+// have a key yet.
+func newYubiKey(ctx context.Context, card string) (*yubiKey, error) {
+	y := cachedYubiKey(card)
+
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	if y.serialNumber != 0 {
+		return y, nil
 	}
-	defer yk.Close()
 
-	y.serialNumber, err = yk.Serial()
+	if y.handle == nil {
+		handle, err := connectCard(card)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		y.handle = handle
+	}
+
+	serialNumber, err := y.handle.Serial()
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	y.serialNumber = serialNumber
 
 	return y, nil
 }
 
+// acquire returns this card's shared PIV connection, opening it if necessary.
+// The caller must call release once done, instead of closing the returned
+// connection directly.
+func (y *yubiKey) acquire() (*piv.YubiKey, error) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	if y.handle == nil {
+		handle, err := connectCard(y.card)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		y.handle = handle
+	}
+
+	y.refCount++
+	return y.handle, nil
+}
+
+// release gives up a reference acquired via acquire.
+func (y *yubiKey) release() {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	if y.refCount > 0 {
+		y.refCount--
+	}
+}
+
+// Shutdown closes this card's shared PIV connection. It is a no-op if the
+// connection is not open. Shutdown fails if operations are still in flight;
+// callers should only invoke it once their context has been cancelled and
+// any in-progress operations have had a chance to finish.
+func (y *yubiKey) Shutdown(ctx context.Context) error {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	if y.handle == nil {
+		return nil
+	}
+	if y.refCount > 0 {
+		return trace.BadParameter("cannot shut down YubiKey PIV connection while %d operations are in progress", y.refCount)
+	}
+
+	err := y.handle.Close()
+	y.handle = nil
+	y.serialNumber = 0
+	return trace.Wrap(err)
+}
+
+// minFirmwareVersionForAlgorithm returns the minimum YubiKey firmware version required to
+// generate a key of the given algorithm, and whether such a minimum is enforced at all.
+func minFirmwareVersionForAlgorithm(algorithm piv.Algorithm) (piv.Version, bool) {
+	switch algorithm {
+	case piv.AlgorithmEd25519, piv.AlgorithmX25519, piv.AlgorithmRSA3072, piv.AlgorithmRSA4096:
+		return piv.Version{Major: 5, Minor: 7, Patch: 0}, true
+	default:
+		return piv.Version{}, false
+	}
+}
+
+func versionLess(v, than piv.Version) bool {
+	if v.Major != than.Major {
+		return v.Major < than.Major
+	}
+	if v.Minor != than.Minor {
+		return v.Minor < than.Minor
+	}
+	return v.Patch < than.Patch
+}
+
+// checkAlgorithmSupported returns a clear, actionable error if the connected YubiKey's
+// firmware does not support the requested algorithm, rather than letting the request
+// fail with an opaque PIV APDU error.
+func checkAlgorithmSupported(yk *piv.YubiKey, algorithm piv.Algorithm) error {
+	minVersion, restricted := minFirmwareVersionForAlgorithm(algorithm)
+	if !restricted {
+		return nil
+	}
+	if versionLess(yk.Version(), minVersion) {
+		return trace.BadParameter(
+			"YubiKey firmware %d.%d.%d does not support this key algorithm; firmware %d.%d.%d or later is required",
+			yk.Version().Major, yk.Version().Minor, yk.Version().Patch,
+			minVersion.Major, minVersion.Minor, minVersion.Patch)
+	}
+	return nil
+}
+
 // generatePrivateKey generates a new private key from the given PIV slot with the given PIV policies.
-func (y *yubiKey) generatePrivateKey(ctx context.Context, slot piv.Slot, touchPolicy piv.TouchPolicy) (*YubiKeyPrivateKey, error) {
-	yk, err := y.open()
+func (y *yubiKey) generatePrivateKey(ctx context.Context, slot piv.Slot, touchPolicy piv.TouchPolicy, pinPolicy piv.PINPolicy, algorithm piv.Algorithm, managementKey []byte, policy PrivateKeyPolicy) (*YubiKeyPrivateKey, error) {
+	yk, err := y.acquire()
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	defer yk.Close()
+	defer y.release()
+
+	if err := checkAlgorithmSupported(yk, algorithm); err != nil {
+		return nil, trace.Wrap(err)
+	}
 
 	opts := piv.Key{
-		Algorithm:   piv.AlgorithmEC256,
-		PINPolicy:   piv.PINPolicyNever,
+		Algorithm:   algorithm,
+		PINPolicy:   pinPolicy,
 		TouchPolicy: touchPolicy,
 	}
 
-	if slot == pivSlotWithTouch {
+	if policy.requiresTouch() {
 		cancelTouchPrompt := delayedTouchPrompt(generateKeyTouchPromptDelay)
 		defer cancelTouchPrompt()
 	}
 
-	pub, err := yk.GenerateKey(piv.DefaultManagementKey, slot, opts)
+	pub, err := yk.GenerateKey(managementKey, slot, opts)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -282,42 +543,58 @@ func (y *yubiKey) generatePrivateKey(ctx context.Context, slot piv.Slot, touchPo
 		return nil, trace.Wrap(err)
 	}
 
-	cert, err := selfSignedTeleportClientCertificate(priv, pub)
+	cert, err := selfSignedTeleportClientCertificate(priv, pub, policy)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
 	// Store a self-signed certificate to mark this slot as used by tsh.
-	if err = yk.SetCertificate(piv.DefaultManagementKey, slot, cert); err != nil {
+	if err = yk.SetCertificate(managementKey, slot, cert); err != nil {
 		return nil, trace.Wrap(err)
 	}
 
-	return newYubiKeyPrivateKey(ctx, y, slot, pub)
+	return newYubiKeyPrivateKey(ctx, y, slot, pub, policy)
 }
 
-// getPrivateKey gets an existing private key from the given PIV slot.
-func (y *yubiKey) getPrivateKey(ctx context.Context, slot piv.Slot) (*YubiKeyPrivateKey, error) {
-	yk, err := y.open()
+// getPrivateKey gets an existing private key from the given PIV slot. If expectedPolicy
+// is not PrivateKeyPolicyNone, the slot's marker certificate must have been provisioned
+// with a policy satisfying it, or a mismatch error is returned guiding the user to reset
+// the slot.
+func (y *yubiKey) getPrivateKey(ctx context.Context, slot piv.Slot, expectedPolicy PrivateKeyPolicy) (*YubiKeyPrivateKey, error) {
+	yk, err := y.acquire()
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	defer yk.Close()
+	defer y.release()
 
 	// Check the slot's certificate to see if it contains a self signed Teleport Client cert.
+	// Only a truly empty slot is reported as NotFound: that's the one case it's safe for the
+	// caller to fall back to generating a new key. A slot occupied by a foreign, non-Teleport
+	// cert is reported as AlreadyExists instead, so the caller treats it the same as a policy
+	// mismatch below and refuses to overwrite it.
 	cert, err := yk.Certificate(slot)
 	if err != nil || cert == nil {
 		return nil, trace.NotFound("YubiKey certificate slot is empty, expected a Teleport Client cert")
 	} else if len(cert.Subject.Organization) == 0 || cert.Subject.Organization[0] != certOrgName {
-		return nil, trace.NotFound("YubiKey certificate slot contained unknown certificate:\n%+v", cert)
+		return nil, trace.AlreadyExists("YubiKey PIV slot %x is already populated with a non-Teleport certificate:\n%+v; run `tsh piv reset --slot %x` to reprovision it",
+			slot.Key, cert, slot.Key)
+	}
+
+	slotPolicy := privateKeyPolicyFromCertificate(cert)
+	if !expectedPolicy.IsSatisfiedBy(slotPolicy) {
+		return nil, trace.BadParameter("YubiKey PIV slot %x was already provisioned with private key policy %q, which does not satisfy the requested policy %q; run `tsh piv reset --slot %x` to reprovision it",
+			slot.Key, slotPolicy, expectedPolicy, slot.Key)
 	}
 
-	return newYubiKeyPrivateKey(ctx, y, slot, cert.PublicKey)
+	return newYubiKeyPrivateKey(ctx, y, slot, cert.PublicKey, slotPolicy)
 }
 
-// open a connection to YubiKey PIV module. The returned connection should be closed once
-// it's been used. The YubiKey PIV module itself takes some additional time to handle closed
-// connections, so we use a retry loop to give the PIV module time to close prior connections.
-func (y *yubiKey) open() (yk *piv.YubiKey, err error) {
+// connectCard opens a connection to the YubiKey PIV module behind the given reader name.
+// The returned connection should be closed once it's been used (or, for a yubiKey's shared
+// connection, once Shutdown is called). The YubiKey PIV module itself takes some additional
+// time to handle closed connections, so we use a retry loop to give the PIV module time to
+// close prior connections.
+func connectCard(card string) (yk *piv.YubiKey, err error) {
 	linearRetry, err := retryutils.NewLinear(retryutils.LinearConfig{
 		// If a PIV connection has just been closed, it take ~5 ms to become
 		// available to new connections. For this reason, we initially wait a
@@ -338,7 +615,7 @@ func (y *yubiKey) open() (yk *piv.YubiKey, err error) {
 	defer cancel()
 
 	err = linearRetry.For(retryCtx, func() error {
-		yk, err = piv.Open(y.card)
+		yk, err = piv.Open(card)
 		if err != nil && !isRetryError(err) {
 			return retryutils.PermanentRetryError(err)
 		}
@@ -408,6 +685,9 @@ func findYubiKeyCards() ([]string, error) {
 	return yubiKeyCards, nil
 }
 
+// parsePIVSlot returns the piv.Slot for the given slot key. Both the four standard
+// PIV slots (9a, 9c, 9d, 9e) and the 20 retired key management slots (0x82-0x95) are
+// supported, so operators can partition keys across slots on shared hardware.
 func parsePIVSlot(slotKey uint32) (piv.Slot, error) {
 	switch slotKey {
 	case piv.SlotAuthentication.Key:
@@ -430,18 +710,24 @@ func parsePIVSlot(slotKey uint32) (piv.Slot, error) {
 // certOrgName is used to identify Teleport Client self-signed certificates stored in yubiKey PIV slots.
 const certOrgName = "teleport"
 
-func selfSignedTeleportClientCertificate(priv crypto.PrivateKey, pub crypto.PublicKey) (*x509.Certificate, error) {
+// selfSignedTeleportClientCertificate creates a certificate used to mark a PIV slot as
+// provisioned by a Teleport Client. The private key policy the slot was provisioned with
+// is recorded in the Subject so that a later client can detect a mismatch between what it
+// requires and what's already on the slot.
+func selfSignedTeleportClientCertificate(priv crypto.PrivateKey, pub crypto.PublicKey, policy PrivateKeyPolicy) (*x509.Certificate, error) {
 	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
 	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit) // see crypto/tls/generate_cert.go
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 	cert := &x509.Certificate{
-		SerialNumber: serialNumber,
-		PublicKey:    pub,
+		SerialNumber:       serialNumber,
+		PublicKey:          pub,
+		SignatureAlgorithm: signatureAlgorithmForPublicKey(pub),
 		Subject: pkix.Name{
 			Organization:       []string{certOrgName},
 			OrganizationalUnit: []string{api.Version},
+			CommonName:         string(policy),
 		},
 	}
 	if cert.Raw, err = x509.CreateCertificate(rand.Reader, cert, cert, pub, priv); err != nil {
@@ -450,6 +736,40 @@ func selfSignedTeleportClientCertificate(priv crypto.PrivateKey, pub crypto.Publ
 	return cert, nil
 }
 
+// signatureAlgorithmForPublicKey returns the x509.SignatureAlgorithm that matches the
+// given public key's type, so a self-signed marker certificate's SignatureAlgorithm is
+// consistent with the algorithm the PIV slot was provisioned with.
+func signatureAlgorithmForPublicKey(pub crypto.PublicKey) x509.SignatureAlgorithm {
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		switch key.Curve.Params().BitSize {
+		case 384:
+			return x509.ECDSAWithSHA384
+		default:
+			return x509.ECDSAWithSHA256
+		}
+	case *rsa.PublicKey:
+		return x509.SHA256WithRSA
+	case ed25519.PublicKey:
+		return x509.PureEd25519
+	default:
+		return x509.UnknownSignatureAlgorithm
+	}
+}
+
+// privateKeyPolicyFromCertificate returns the PrivateKeyPolicy recorded on a PIV slot's
+// Teleport Client marker certificate. Certificates that record no policy, such as those
+// created before this field existed, report PrivateKeyPolicyHardwareKey whichever slot they
+// are on, the touch slot 9c included.
+func privateKeyPolicyFromCertificate(cert *x509.Certificate) PrivateKeyPolicy {
+	switch PrivateKeyPolicy(cert.Subject.CommonName) {
+	case PrivateKeyPolicyHardwareKeyTouch, PrivateKeyPolicyHardwareKeyPIN, PrivateKeyPolicyHardwareKeyTouchAndPIN:
+		return PrivateKeyPolicy(cert.Subject.CommonName)
+	default:
+		return PrivateKeyPolicyHardwareKey
+	}
+}
+
 // YubiKeys require touch when generating a private key that requires touch, or using
 // a private key (Sign) with touch required. Unfortunately, there is no good way to
 // check whether touch is cached by the PIV module at a given time. In order to require
@@ -21,8 +21,8 @@ import (
 	"fmt"
 	"github.com/gravitational/teleport/lib/srv/db/common"
 	"github.com/gravitational/teleport/lib/srv/db/sqlserver/kinit"
-	"github.com/jcmturner/gokrb5/v8/credentials"
 	"strings"
+	"sync"
 
 	"github.com/jcmturner/gokrb5/v8/client"
 	"github.com/jcmturner/gokrb5/v8/config"
@@ -32,6 +32,110 @@ import (
 	"github.com/gravitational/trace"
 )
 
+// pkinitCache caches kinit credentials caches across sessions so a burst of new database
+// connections for the same Windows identity doesn't each run their own PKINIT exchange. It's
+// package-level rather than a connector field because a fresh *connector is created per
+// session, but the TGTs it caches are safe - and worth - sharing across all of them.
+var (
+	pkinitCacheOnce sync.Once
+	pkinitCache     *kinit.Cache
+)
+
+func getPKInitCache(sessionCtx *common.Session) (*kinit.Cache, error) {
+	var err error
+	pkinitCacheOnce.Do(func() {
+		pkinitCache, err = kinit.NewCache(kinit.CacheConfig{
+			NewKInit: func(ctx context.Context, username, realm string) (*kinit.KInit, error) {
+				cn := fmt.Sprintf("%s@%s", username, realm)
+				k := kinit.New(
+					"cacert.pem",
+					"cakey.pem",
+					"usercert.pem",
+					"userkey.pem",
+					username,
+					"kinit.cache",
+					strings.ToUpper(realm),
+					realm,
+					realm,
+				)
+				// Resolve the realm's KDCs over DNS rather than assuming the KDC hostname
+				// equals the realm name, so a child domain in a forest (whose KDCs live
+				// elsewhere) authenticates correctly. Best-effort: fall back to the
+				// hardcoded KDCHostName set by kinit.New above if discovery fails, e.g. on
+				// a network without the expected SRV records.
+				if err := k.DiscoverKDCs(ctx); err != nil {
+					k.Log.WithError(err).Warn("Falling back to realm name as KDC hostname; DNS-based KDC discovery failed.")
+				}
+				// these extensions are required for kerberos x509 auth; https://web.mit.edu/kerberos/krb5-1.13/doc/admin/pkinit.html
+				if err := k.GenerateKDCExtensions("kdc.extensions"); err != nil {
+					return nil, trace.Wrap(err)
+				}
+				// A PEM-backed provider until a TPM is configured for this agent; see
+				// kinit.TPMKeyProvider for the hardware-backed alternative.
+				provider, err := kinit.NewPEMKeyProvider()
+				if err != nil {
+					return nil, trace.Wrap(err)
+				}
+				if err := k.GenerateClientCertKey(context.Background(), provider, "kdc.extensions", "US", "MA", "Boston", "Teleport", "Eng", cn, cn, "."); err != nil {
+					return nil, trace.Wrap(err)
+				}
+				return k, nil
+			},
+		})
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return pkinitCache, nil
+}
+
+// authMode selects how the connector negotiates Kerberos with Active Directory.
+type authMode string
+
+const (
+	// authModeKeytab is the original flow: a service account keytab exported up front.
+	authModeKeytab authMode = "keytab"
+	// authModePKINIT authenticates with an ephemeral client certificate instead of a keytab.
+	authModePKINIT authMode = "pkinit"
+	// authModeSSPI uses the Windows SSPI Negotiate package under the agent's own process
+	// identity instead of a keytab; only available when the agent runs on Windows.
+	authModeSSPI authMode = "sspi"
+)
+
+// resolveAuthMode picks the authMode sessionCtx's database should negotiate with.
+//
+// TODO(gmemstr): this should read an AuthMode field off the database's AD config, the same
+// way getAuth/getPKAuth already read KeytabFile/Domain/SPN/Krb5File off it, so operators can
+// opt a database into pkinit or sspi. That field doesn't exist in this checkout's AD config
+// type (defined outside this package, out of scope for this series) - wire it through once
+// it's added there instead of guessing at its shape here. Until then every database keeps
+// authenticating exactly as before.
+func resolveAuthMode(sessionCtx *common.Session) authMode {
+	return authModeKeytab
+}
+
+// authProvider is the SQL Server driver's Kerberos auth contract: an initial SPNEGO token,
+// followed by whatever continuation tokens the negotiated mechanism still needs. The gokrb5
+// paths (getAuth, getPKAuth) complete in a single leg; SSPI Negotiate (Windows-only) does not.
+type authProvider interface {
+	InitialBytes() ([]byte, error)
+	NextBytes([]byte) ([]byte, error)
+	Free()
+}
+
+// getAuthProvider resolves sessionCtx's authMode and dispatches to the matching
+// authProvider implementation.
+func (c *connector) getAuthProvider(ctx context.Context, sessionCtx *common.Session) (authProvider, error) {
+	switch resolveAuthMode(sessionCtx) {
+	case authModePKINIT:
+		return c.getPKAuth(ctx, sessionCtx)
+	case authModeSSPI:
+		return c.getSSPIAuth(ctx, sessionCtx)
+	default:
+		return c.getAuth(sessionCtx)
+	}
+}
+
 // getAuth returns Kerberos authenticator used by SQL Server driver.
 //
 // TODO(r0mant): Unit-test this. In-memory Kerberos server?
@@ -99,43 +203,14 @@ func (c *connector) getAuth(sessionCtx *common.Session) (*krbAuth, error) {
 // - gpupdate.exe /force  // update group policy for changes to take effect
 // - New-GPO -Name <name> | New-GPLink -Target $((Get-ADDomain).DistinguishedName)  // Create a new group policy object and link it to the active directory domain
 func (c *connector) getPKAuth(ctx context.Context, sessionCtx *common.Session) (*krbAuth, error) {
-
-	// super hacky, I just placed certs next to the teleport binary
-	// some of this information we will want in config, such as the domain controller/admin server address and the realm
-	k := kinit.New(
-		"cacert.pem",
-		"cakey.pem",
-		"usercert.pem",
-		"userkey.pem",
-		sessionCtx.Identity.Username,
-		"kinit.cache",
-		strings.ToUpper(sessionCtx.Database.GetAD().Domain),
-		sessionCtx.Database.GetAD().Domain,
-		sessionCtx.Database.GetAD().Domain,
-	)
-
-	// these extensions are required for kerberos x509 auth; https://web.mit.edu/kerberos/krb5-1.13/doc/admin/pkinit.html
-	err := k.GenerateKDCExtensions("kdc.extensions")
-	if err != nil {
-		return nil, trace.Wrap(err)
-	}
-
-	cn := fmt.Sprintf("%s@%s", sessionCtx.Identity.Username, sessionCtx.Database.GetAD().Domain)
-
-	// generate ephemeral client cert and keypair
-	err = k.GenerateClientCertKey(ctx, "kdc.extensions", "US", "MA", "Boston", "Teleport", "Eng", cn, cn, ".")
-	if err != nil {
-		return nil, trace.Wrap(err)
-	}
-
-	// create the kinit credentials cache using the previously prepared cert/key pair
-	err = k.CreateOrAppendCredentialsCache(ctx)
+	cache, err := getPKInitCache(sessionCtx)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
-	// Load CCache.
-	cc, err := credentials.LoadCCache(k.CacheName)
+	// A thin lookup: Cache only runs a PKINIT exchange (ephemeral cert + AS-REQ/AS-REP) on a
+	// miss, otherwise it hands back the TGT it already minted for this identity.
+	cc, err := cache.Get(ctx, sessionCtx.Identity.Username, sessionCtx.Database.GetAD().Domain)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -0,0 +1,266 @@
+// Copyright 2023 Gravitational, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinit
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jonboulle/clockwork"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// defaultRefreshWindow is how long before a TGT's endtime Cache proactively refreshes it.
+	defaultRefreshWindow = 5 * time.Minute
+	// defaultRefreshJitter is the maximum random delay added on top of a scheduled refresh.
+	defaultRefreshJitter = time.Minute
+)
+
+// cacheKey identifies a cached TGT by the identity it was issued to.
+type cacheKey struct {
+	username string
+	realm    string
+}
+
+func (k cacheKey) String() string {
+	return fmt.Sprintf("%s@%s", k.username, k.realm)
+}
+
+// cacheEntry is one cached TGT, alongside the lifetime Cache uses to decide when to refresh
+// it. endTime/renewTill come from the AS-REP ticket the PKINIT exchange returned, not from
+// a fixed TTL, so the cache never outlives what the KDC actually issued.
+type cacheEntry struct {
+	ccache    *credentials.CCache
+	endTime   time.Time
+	renewTill time.Time
+}
+
+// CacheConfig configures a Cache.
+type CacheConfig struct {
+	// NewKInit builds a KInit ready to have CreateOrAppendCredentialsCache called on it for
+	// the given username/realm. Cache calls this on every miss and on every background
+	// refresh, passing through whatever context triggered it (ctx on a miss, a background
+	// context for a proactive refresh) so NewKInit can use it for KDC discovery.
+	NewKInit func(ctx context.Context, username, realm string) (*KInit, error)
+	// RefreshWindow is how long before a TGT's endtime Cache proactively refreshes it in the
+	// background. Defaults to defaultRefreshWindow.
+	RefreshWindow time.Duration
+	// RefreshJitter is the maximum random delay added on top of a scheduled refresh, so a
+	// burst of sessions that minted their TGT around the same time don't all refresh at once.
+	// Defaults to defaultRefreshJitter.
+	RefreshJitter time.Duration
+	// Clock is used for all cache timing decisions. Defaults to the real clock.
+	Clock clockwork.Clock
+}
+
+func (cfg *CacheConfig) checkAndSetDefaults() error {
+	if cfg.NewKInit == nil {
+		return trace.BadParameter("NewKInit is required")
+	}
+	if cfg.RefreshWindow == 0 {
+		cfg.RefreshWindow = defaultRefreshWindow
+	}
+	if cfg.RefreshJitter == 0 {
+		cfg.RefreshJitter = defaultRefreshJitter
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clockwork.NewRealClock()
+	}
+	return nil
+}
+
+// Cache keys kinit credentials caches by (username, realm), reusing a TGT across database
+// connections instead of running a fresh PKINIT exchange per session, and refreshing entries
+// asynchronously ahead of expiry. Concurrent requests for the same principal are serialized
+// through a singleflight.Group so a burst of new sessions for one user only triggers one
+// PKINIT flow.
+type Cache struct {
+	cfg   CacheConfig
+	group singleflight.Group
+
+	mu      sync.Mutex
+	entries map[cacheKey]*cacheEntry
+
+	metrics cacheMetrics
+}
+
+// NewCache creates a Cache from cfg.
+func NewCache(cfg CacheConfig) (*Cache, error) {
+	if err := cfg.checkAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Cache{
+		cfg:     cfg,
+		entries: make(map[cacheKey]*cacheEntry),
+		metrics: newCacheMetrics(),
+	}, nil
+}
+
+// Get returns a credentials cache for (username, realm), reusing a cached TGT if one is
+// present and has not reached its endtime (a TGT within RefreshWindow of it is still returned,
+// and refreshed in the background), and otherwise running a PKINIT exchange to mint one -
+// collapsing concurrent callers for the same principal into a single exchange.
+func (c *Cache) Get(ctx context.Context, username, realm string) (*credentials.CCache, error) {
+	key := cacheKey{username: username, realm: realm}
+
+	if entry := c.lookup(key); entry != nil {
+		c.metrics.hits.Inc()
+		c.maybeScheduleRefresh(key, entry)
+		return entry.ccache, nil
+	}
+	c.metrics.misses.Inc()
+
+	result, err, _ := c.group.Do(key.String(), func() (interface{}, error) {
+		// Another goroutine may have populated the entry while we waited to enter Do.
+		if entry := c.lookup(key); entry != nil {
+			return entry, nil
+		}
+		return c.refresh(ctx, key)
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	entry := result.(*cacheEntry)
+	c.maybeScheduleRefresh(key, entry)
+	return entry.ccache, nil
+}
+
+// lookup returns the cached entry for key if it exists and hasn't passed its endtime.
+func (c *Cache) lookup(key cacheKey) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.cfg.Clock.Now().Before(entry.endTime) {
+		return nil
+	}
+	return entry
+}
+
+// maybeScheduleRefresh kicks off a background refresh once entry has entered its
+// RefreshWindow, jittered so concurrent sessions for the same principal don't all refresh at
+// the same instant. Only one refresh per principal runs at a time, via the singleflight.
+func (c *Cache) maybeScheduleRefresh(key cacheKey, entry *cacheEntry) {
+	if c.cfg.Clock.Now().Before(entry.endTime.Add(-c.cfg.RefreshWindow)) {
+		return
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(c.cfg.RefreshJitter) + 1))
+	go func() {
+		<-c.cfg.Clock.After(jitter)
+		if _, err, _ := c.group.Do(key.String(), func() (interface{}, error) {
+			if entry := c.lookup(key); entry != nil && c.cfg.Clock.Now().Before(entry.endTime.Add(-c.cfg.RefreshWindow)) {
+				return entry, nil
+			}
+			return c.refresh(context.Background(), key)
+		}); err != nil {
+			c.metrics.refreshFailures.Inc()
+		}
+	}()
+}
+
+// refresh runs a PKINIT exchange for key and stores the resulting entry, replacing whatever
+// was cached for this principal before.
+func (c *Cache) refresh(ctx context.Context, key cacheKey) (*cacheEntry, error) {
+	k, err := c.cfg.NewKInit(ctx, key.username, key.realm)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := k.CreateOrAppendCredentialsCache(ctx); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	ccache, err := k.CCache()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(ccache.Credentials) == 0 {
+		return nil, trace.BadParameter("PKINIT exchange for %s returned no credentials", key)
+	}
+	cred := ccache.Credentials[0]
+
+	entry := &cacheEntry{
+		ccache:    ccache,
+		endTime:   cred.EndTime,
+		renewTill: cred.RenewTill,
+	}
+	c.metrics.ticketLifetime.Observe(cred.EndTime.Sub(cred.AuthTime).Seconds())
+
+	c.mu.Lock()
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	return entry, nil
+}
+
+// cacheMetrics are the Cache counters/histograms surfaced for monitoring.
+type cacheMetrics struct {
+	hits            prometheus.Counter
+	misses          prometheus.Counter
+	refreshFailures prometheus.Counter
+	ticketLifetime  prometheus.Histogram
+}
+
+func newCacheMetrics() cacheMetrics {
+	return cacheMetrics{
+		hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "teleport",
+			Subsystem: "sqlserver_kinit",
+			Name:      "cache_hits_total",
+			Help:      "Number of times a cached kinit TGT was reused instead of minting a new one.",
+		}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "teleport",
+			Subsystem: "sqlserver_kinit",
+			Name:      "cache_misses_total",
+			Help:      "Number of times a database session had to wait on a fresh PKINIT exchange.",
+		}),
+		refreshFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "teleport",
+			Subsystem: "sqlserver_kinit",
+			Name:      "cache_refresh_failures_total",
+			Help:      "Number of background TGT refreshes that failed.",
+		}),
+		ticketLifetime: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "teleport",
+			Subsystem: "sqlserver_kinit",
+			Name:      "ticket_lifetime_seconds",
+			Help:      "Lifetime (endtime - authtime) of TGTs issued by the KDC.",
+			Buckets:   prometheus.ExponentialBuckets(60, 2, 10),
+		}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Cache) Describe(ch chan<- *prometheus.Desc) {
+	c.metrics.hits.Describe(ch)
+	c.metrics.misses.Describe(ch)
+	c.metrics.refreshFailures.Describe(ch)
+	c.metrics.ticketLifetime.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Cache) Collect(ch chan<- prometheus.Metric) {
+	c.metrics.hits.Collect(ch)
+	c.metrics.misses.Collect(ch)
+	c.metrics.refreshFailures.Collect(ch)
+	c.metrics.ticketLifetime.Collect(ch)
+}
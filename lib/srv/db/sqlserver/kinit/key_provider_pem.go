@@ -0,0 +1,86 @@
+// Copyright 2023 Gravitational, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinit
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+
+	"github.com/gravitational/trace"
+)
+
+// PEMKeyProvider is the default KeyProvider: an RSA key held in memory (and, if Write is
+// called, persisted to disk as a PEM file), the same key material the pre-KeyProvider
+// implementation generated directly inside GenerateClientCertKey.
+type PEMKeyProvider struct {
+	key *rsa.PrivateKey
+}
+
+// NewPEMKeyProvider generates a fresh 4096-bit RSA key.
+func NewPEMKeyProvider() (*PEMKeyProvider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 4096)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &PEMKeyProvider{key: key}, nil
+}
+
+// LoadPEMKeyProvider reads an RSA private key previously written by Write.
+func LoadPEMKeyProvider(path string) (*PEMKeyProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, trace.BadParameter("%s does not contain a PEM private key", path)
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &PEMKeyProvider{key: key}, nil
+}
+
+// Write persists the key to path as a PEM-encoded PKCS#1 private key.
+func (p *PEMKeyProvider) Write(path string) error {
+	keyBytes := x509.MarshalPKCS1PrivateKey(p.key)
+	return trace.Wrap(os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: keyBytes}), 0600))
+}
+
+func (p *PEMKeyProvider) Public() crypto.PublicKey {
+	return &p.key.PublicKey
+}
+
+func (p *PEMKeyProvider) SignPKINIT(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.key, opts.HashFunc(), digest)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return sig, nil
+}
+
+func (p *PEMKeyProvider) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	plain, err := rsa.DecryptPKCS1v15(rand.Reader, p.key, ciphertext)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return plain, nil
+}
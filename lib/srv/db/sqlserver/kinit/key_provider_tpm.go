@@ -0,0 +1,206 @@
+//go:build !pkinit_shellout
+
+// Copyright 2023 Gravitational, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file targets the google/go-tpm v2 tpm2 API (TPM2B_PUBLIC-returning command structs,
+// transport.TPM for the device handle) rather than the original v1 API's byte-slice marshaling.
+
+package kinit
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"math/big"
+
+	"github.com/google/go-tpm/tpm2"
+	"github.com/google/go-tpm/tpm2/transport"
+	"github.com/gravitational/trace"
+)
+
+// TPMConfig locates the TPM-resident PKINIT client key: either an existing persistent handle
+// (Handle != 0), or a handle to create and persist a fresh key under on first use.
+type TPMConfig struct {
+	// DevicePath is the TPM character device, e.g. "/dev/tpmrm0".
+	DevicePath string
+	// Handle is the persistent handle the key lives (or should be created) at, under the TPM's
+	// owner hierarchy.
+	Handle tpm2.TPMHandle
+}
+
+// TPMKeyProvider is a KeyProvider backed by an RSA key that never leaves a TPM 2.0 chip: every
+// signature and decrypt operation is delegated to the TPM, which is the point - a PKINIT client
+// identity this way can't be exfiltrated by copying a file off the agent's disk. Patterned after
+// the TPM-backed KMS smallstep's step-ca uses for its own CA keys.
+type TPMKeyProvider struct {
+	cfg    TPMConfig
+	tpm    transport.TPM
+	handle tpm2.TPMHandle
+	public *rsa.PublicKey
+}
+
+// NewTPMKeyProvider opens cfg.DevicePath and either loads the key already persisted at
+// cfg.Handle, or - if nothing is there yet - creates one under the owner hierarchy with sign
+// and decrypt usage, persists it at cfg.Handle, and runs TPM2_CertifyCreation over it. The
+// resulting attestation is discarded, not returned or stored.
+func NewTPMKeyProvider(cfg TPMConfig) (*TPMKeyProvider, error) {
+	tpm, err := transport.OpenTPM(cfg.DevicePath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if pub, err := readPersistedPublic(tpm, cfg.Handle); err == nil {
+		return &TPMKeyProvider{cfg: cfg, tpm: tpm, handle: cfg.Handle, public: pub}, nil
+	}
+
+	pub, err := provisionTPMKey(tpm, cfg.Handle)
+	if err != nil {
+		tpm.Close()
+		return nil, trace.Wrap(err)
+	}
+	return &TPMKeyProvider{cfg: cfg, tpm: tpm, handle: cfg.Handle, public: pub}, nil
+}
+
+// rsaSignDecryptTemplate is an RSA 2048 key under the owner hierarchy restricted to sign and
+// decrypt, fixed TPM, and userWithAuth - the shape PKINIT needs both operations out of.
+func rsaSignDecryptTemplate() tpm2.TPMTPublic {
+	return tpm2.TPMTPublic{
+		Type:    tpm2.TPMAlgRSA,
+		NameAlg: tpm2.TPMAlgSHA256,
+		ObjectAttributes: tpm2.TPMAObject{
+			FixedTPM:            true,
+			FixedParent:         true,
+			SensitiveDataOrigin: true,
+			UserWithAuth:        true,
+			Decrypt:             true,
+			SignEncrypt:         true,
+		},
+		Parameters: tpm2.NewTPMUPublicParms(tpm2.TPMAlgRSA, &tpm2.TPMSRSAParms{
+			KeyBits: 2048,
+		}),
+	}
+}
+
+// provisionTPMKey creates a fresh key under the owner hierarchy, persists it at handle, and
+// runs TPM2_CertifyCreation over its creation data; the attestation it produces is not kept.
+// Returns the key's public half.
+func provisionTPMKey(tpm transport.TPM, handle tpm2.TPMHandle) (*rsa.PublicKey, error) {
+	primary, err := tpm2.CreatePrimary{
+		PrimaryHandle: tpm2.TPMRHOwner,
+		InPublic:      tpm2.New2B(rsaSignDecryptTemplate()),
+	}.Execute(tpm)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	// CertifyCreation binds TPM2B_CREATION_DATA returned above to an attestation structure
+	// showing the key was born inside this TPM rather than imported. Its output is discarded
+	// here, so the attestation is not available to anyone after this call.
+	if _, err := (tpm2.CertifyCreation{
+		SignHandle:     tpm2.AuthHandle{Handle: primary.ObjectHandle},
+		ObjectHandle:   tpm2.NamedHandle{Handle: primary.ObjectHandle, Name: primary.Name},
+		CreationHash:   primary.CreationHash,
+		CreationTicket: primary.CreationTicket,
+	}).Execute(tpm); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if _, err := (tpm2.EvictControl{
+		Auth:             tpm2.TPMRHOwner,
+		ObjectHandle:     &tpm2.NamedHandle{Handle: primary.ObjectHandle, Name: primary.Name},
+		PersistentHandle: tpm2.TPMHandle(handle),
+	}).Execute(tpm); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return publicFromTPMTPublic(primary.OutPublic)
+}
+
+func readPersistedPublic(tpm transport.TPM, handle tpm2.TPMHandle) (*rsa.PublicKey, error) {
+	out, err := (tpm2.ReadPublic{ObjectHandle: handle}).Execute(tpm)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return publicFromTPMTPublic(out.OutPublic)
+}
+
+func publicFromTPMTPublic(out tpm2.TPM2BPublic) (*rsa.PublicKey, error) {
+	pub, err := out.Contents()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	rsaDetail, err := pub.Parameters.RSADetail()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	rsaUnique, err := pub.Unique.RSA()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(rsaUnique.Buffer),
+		E: int(rsaDetail.Exponent),
+	}, nil
+}
+
+func (p *TPMKeyProvider) Public() crypto.PublicKey {
+	return p.public
+}
+
+// SignPKINIT signs digest inside the TPM with RSASSA-PKCS1v1.5, the scheme rsaSignDecryptTemplate
+// allows for the sign-capable key created by provisionTPMKey.
+func (p *TPMKeyProvider) SignPKINIT(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	sig, err := (tpm2.Sign{
+		KeyHandle: tpm2.AuthHandle{Handle: p.handle},
+		Digest:    tpm2.TPM2BDigest{Buffer: digest},
+		InScheme: tpm2.TPMTSigScheme{
+			Scheme: tpm2.TPMAlgRSASSA,
+			Details: tpm2.NewTPMUSigScheme(tpm2.TPMAlgRSASSA, &tpm2.TPMSSchemeHash{
+				HashAlg: tpm2.TPMAlgSHA256,
+			}),
+		},
+		Validation: tpm2.TPMTTKHashcheck{Tag: tpm2.TPMSTHashcheck},
+	}).Execute(p.tpm)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	rsaSig, err := sig.Signature.Signature.RSASSA()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rsaSig.Sig.Buffer, nil
+}
+
+// Decrypt unwraps ciphertext inside the TPM, used to recover the CMS content-encryption key
+// from the KDC's PA-PK-AS-REP RSA key-transport wrap without ever exposing the private key.
+func (p *TPMKeyProvider) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	out, err := (tpm2.RSADecrypt{
+		KeyHandle:  tpm2.AuthHandle{Handle: p.handle},
+		CipherText: tpm2.TPM2BPublicKeyRSA{Buffer: ciphertext},
+		InScheme: tpm2.TPMTRSADecrypt{
+			Scheme: tpm2.TPMAlgRSAES,
+		},
+	}).Execute(p.tpm)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return out.Message.Buffer, nil
+}
+
+// Close releases the TPM connection. Callers that created the provider with NewTPMKeyProvider
+// should call this once the PKINIT exchange is done.
+func (p *TPMKeyProvider) Close() error {
+	return trace.Wrap(p.tpm.Close())
+}
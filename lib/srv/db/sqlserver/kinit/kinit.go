@@ -18,95 +18,42 @@ package kinit
 
 import (
 	"context"
+	"crypto"
 	"fmt"
-	"github.com/gravitational/trace"
-	"github.com/sirupsen/logrus"
+	"net"
 	"os"
-	"os/exec"
-	"path/filepath"
-)
-
-/*
-//#cgo CFLAGS: -g -Wno-deprecated-declarations
-//#cgo LDFLAGS: -L -lgssapi_krb5 -lkrb5 -lk5crypto -libkrb5support
-//#include "kinit.c"
-*/
-//import "C"
-
-//func KInit(ca, userCert, userKey, cacheName string) error {
-//	ret := C.kinit(C.CString(ca), C.CString(userCert), C.CString(userKey), C.CString(cacheName))
-//	if ret != C.KDC_ERR_NONE {
-//		return trace.Wrap(fmt.Errorf("error returned from kinit: %d", int(ret)))
-//	}
-//	return nil
-//}
-
-const kdcExtensionsFileText = `[ kdc_cert ]
-basicConstraints=CA:FALSE
-
-# Here are some examples of the usage of nsCertType. If it is omitted
-keyUsage = nonRepudiation, digitalSignature, keyEncipherment, keyAgreement
-
-#Pkinit EKU
-extendedKeyUsage = 1.3.6.1.5.2.3.5
-
-subjectKeyIdentifier=hash
-authorityKeyIdentifier=keyid,issuer
-
-# Copy subject details
-
-issuerAltName=issuer:copy
-
-# Add id-pkinit-san (pkinit subjectAlternativeName)
-subjectAltName=otherName:1.3.6.1.5.2.2;SEQUENCE:kdc_princ_name
-
-[kdc_princ_name]
-realm = EXP:0, GeneralString:${ENV::REALM}
-principal_name = EXP:1, SEQUENCE:kdc_principal_seq
-
-[kdc_principal_seq]
-name_type = EXP:0, INTEGER:1
-name_string = EXP:1, SEQUENCE:kdc_principals
-
-[kdc_principals]
-princ1 = GeneralString:krbtgt
-princ2 = GeneralString:${ENV::REALM}
+	"sort"
+	"strings"
 
-[ client_cert ]
-
-# These extensions are added when 'ca' signs a request.
-
-basicConstraints=CA:FALSE
-
-keyUsage = digitalSignature, keyEncipherment, keyAgreement
-
-extendedKeyUsage =  1.3.6.1.5.2.3.4
-subjectKeyIdentifier=hash
-authorityKeyIdentifier=keyid,issuer
-
-
-subjectAltName=otherName:1.3.6.1.5.2.2;SEQUENCE:princ_name
-
-
-# Copy subject details
-
-issuerAltName=issuer:copy
-
-[princ_name]
-realm = EXP:0, GeneralString:${ENV::REALM}
-principal_name = EXP:1, SEQUENCE:principal_seq
-
-[principal_seq]
-name_type = EXP:0, INTEGER:1
-name_string = EXP:1, SEQUENCE:principals
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/sirupsen/logrus"
+)
 
-[principals]
-princ1 = GeneralString:${ENV::CLIENT}`
+// KeyProvider abstracts the private key behind a PKINIT client certificate, so the PKINIT
+// AS exchange never has to assume that key is sitting in a PEM file readable off disk. The
+// default PEMKeyProvider does exactly that for backwards compatibility, but a TPM-resident key
+// (see TPMKeyProvider) never exposes its private part at all - every operation that needs it
+// is delegated to the TPM.
+type KeyProvider interface {
+	// Public returns the public half of the key, embedded in the client certificate's CSR.
+	Public() crypto.PublicKey
+	// SignPKINIT signs digest (hashed per opts) to produce the AuthPack's CMS signature.
+	SignPKINIT(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error)
+	// Decrypt unwraps the CMS content-encryption key the KDC's PA-PK-AS-REP encrypted to this
+	// key's public half (the RSA key-transport variant of PKINIT; see pkinit_asreq.go).
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
 
 const (
 	DefaultKRBConfig = "/etc/krb5.conf"
 )
 
+// KInit drives a PKINIT exchange against a realm's KDC: given a CA and a user identity it
+// produces a Kerberos credentials cache that can be handed straight to a gokrb5 client. The
+// default implementation (pkinit_cert.go, pkinit_asreq.go) reads the CA cert/key and writes
+// the user cert in-process, keeping the user key behind a KeyProvider; UserKeyPath and
+// CacheName are only consulted by the legacy openssl/kinit code path built with the
+// pkinit_shellout tag.
 type KInit struct {
 	CACertPath   string
 	CAKeyPath    string
@@ -119,7 +66,31 @@ type KInit struct {
 	KDCHostName     string
 	AdminServerName string
 
+	// Realms lists every realm krb5.conf needs a [realms] stanza for: this agent's own realm
+	// plus any foreign realm reachable over cross-realm trust (e.g. a child domain in the same
+	// forest). When set, it takes precedence over RealmName/KDCHostName/AdminServerName for
+	// config generation; DiscoverKDCs populates it automatically. Leave nil to keep the single-
+	// realm behavior those three fields describe.
+	Realms []RealmConfig
+	// DomainRealm maps a DNS domain/host suffix (e.g. "child.example.com") to the realm that
+	// administers it, populating krb5.conf's [domain_realm] section.
+	DomainRealm map[string]string
+	// Capaths describes transitive cross-realm trust paths: Capaths[client][server] is the
+	// intermediate realm a ticket from "client" to "server" must hop through when there's no
+	// direct trust between them. See krb5.conf(5)'s [capaths] section.
+	Capaths map[string]map[string]string
+
 	Log logrus.FieldLogger
+
+	// cache holds the credentials cache produced by CreateOrAppendCredentialsCache. It's kept
+	// in memory rather than written to CacheName so the PKINIT AS exchange never has to touch
+	// disk; call CCache to retrieve it.
+	cache *credentials.CCache
+
+	// keyProvider is the client certificate's private key, set by GenerateClientCertKey.
+	// CreateOrAppendCredentialsCache signs and decrypts through it instead of loading
+	// UserKeyPath from disk.
+	keyProvider KeyProvider
 }
 
 func New(ca, caKey, userCert, userKey, user, cacheName, realm, kdcHost, adminServer string) *KInit {
@@ -137,122 +108,142 @@ func New(ca, caKey, userCert, userKey, user, cacheName, realm, kdcHost, adminSer
 	}
 }
 
-// CreateOrAppendCredentialsCache creates or appends to an existing credentials cache. There must be a valid KDC running
-// at the specified certificate authority address as defined in the CA Certificate
-func (k *KInit) CreateOrAppendCredentialsCache(ctx context.Context) error {
-	cmd := exec.CommandContext(ctx,
-		"kinit",
-		"-X", fmt.Sprintf("X509_anchors=FILE:%s", k.CACertPath),
-		"-X", fmt.Sprintf("X509_user_identity=FILE:%s,%s", k.UserCertPath, k.UserKeyPath), k.UserName,
-		"-c", k.CacheName)
-	data, err := cmd.CombinedOutput()
-	if err != nil {
-		return trace.Wrap(err)
+// CCache returns the credentials cache produced by the last successful call to
+// CreateOrAppendCredentialsCache.
+func (k *KInit) CCache() (*credentials.CCache, error) {
+	if k.cache == nil {
+		return nil, fmt.Errorf("no credentials cache available, CreateOrAppendCredentialsCache must be called first")
 	}
-	// todo better error handling from output/fully wrap libkrb5 for linux
-	k.Log.Debug(string(data))
-	return nil
+	return k.cache, nil
+}
+
+// RealmConfig describes one realm's KDC(s) for a multi-realm krb5.conf: either this agent's
+// own realm or a foreign realm reached over cross-realm trust.
+type RealmConfig struct {
+	Name         string
+	KDCHosts     []string
+	AdminServers []string
 }
 
-// GenerateKDCExtensions file for openssl
-func (k *KInit) GenerateKDCExtensions(path string) error {
-	return os.WriteFile(path, []byte(kdcExtensionsFileText), 0644)
+// realmConfigs returns k.Realms if set, otherwise a single RealmConfig built from the legacy
+// RealmName/KDCHostName/AdminServerName fields.
+func (k *KInit) realmConfigs() []RealmConfig {
+	if len(k.Realms) > 0 {
+		return k.Realms
+	}
+	return []RealmConfig{{
+		Name:         k.RealmName,
+		KDCHosts:     []string{k.KDCHostName},
+		AdminServers: []string{k.AdminServerName},
+	}}
 }
 
-// krb5ConfigString returns a config suitable for a kdc
+// krb5ConfigString renders a krb5.conf with a [realms] stanza per realm in k.realmConfigs(),
+// plus [domain_realm] and [capaths] sections when DomainRealm/Capaths are set - enough for a
+// client to authenticate across a forest of child domains, not just a single realm.
 func (k *KInit) krb5ConfigString() string {
-	return fmt.Sprintf(`[libdefaults]
- default_realm = %s
- rdns = false
+	defaultRealm := k.RealmName
+	if len(k.Realms) > 0 {
+		defaultRealm = k.Realms[0].Name
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[libdefaults]\n default_realm = %s\n rdns = false\n\n", defaultRealm)
+
+	b.WriteString("[realms]\n")
+	for _, realm := range k.realmConfigs() {
+		fmt.Fprintf(&b, " %s = {\n", realm.Name)
+		for _, kdc := range realm.KDCHosts {
+			fmt.Fprintf(&b, "  kdc = %s\n", kdc)
+		}
+		for _, admin := range realm.AdminServers {
+			fmt.Fprintf(&b, "  admin_server = %s\n", admin)
+		}
+		b.WriteString("  pkinit_eku_checking = kpServerAuth\n")
+		for _, kdc := range realm.KDCHosts {
+			fmt.Fprintf(&b, "  pkinit_kdc_hostname = %s\n", kdc)
+		}
+		b.WriteString(" }\n")
+	}
+
+	if len(k.DomainRealm) > 0 {
+		b.WriteString("\n[domain_realm]\n")
+		for _, domain := range sortedKeys(k.DomainRealm) {
+			fmt.Fprintf(&b, " %s = %s\n", domain, k.DomainRealm[domain])
+		}
+	}
+
+	if len(k.Capaths) > 0 {
+		b.WriteString("\n[capaths]\n")
+		for _, client := range sortedKeys(k.Capaths) {
+			fmt.Fprintf(&b, " %s = {\n", client)
+			for _, server := range sortedKeys(k.Capaths[client]) {
+				fmt.Fprintf(&b, "  %s = %s\n", server, k.Capaths[client][server])
+			}
+			b.WriteString(" }\n")
+		}
+	}
 
+	return b.String()
+}
 
-[realms]
- %s = {
-  kdc = %s
-  admin_server = %s
-  pkinit_eku_checking = kpServerAuth
-  pkinit_kdc_hostname = %s
- }`, k.RealmName, k.RealmName, k.KDCHostName, k.AdminServerName, k.KDCHostName)
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func (k *KInit) WriteKRB5Config(path string) error {
 	return os.WriteFile(path, []byte(k.krb5ConfigString()), 0644)
 }
 
-// GenerateKDCCertKey generates an intermediary certificate and key pair specifically for a Kerberos Key Distribution Center
-func (k *KInit) GenerateKDCCertKey(ctx context.Context, extensionsFile, country, stateProvince, locality, orgName, unit, commonName, email, outDir string) error {
-	cmd := exec.CommandContext(ctx,
-		"openssl", "req", "-newkey", "rsa:4096", "-sha256", "-nodes",
-		"-keyout", filepath.Join(outDir, "kdckey.pem"),
-		"-out", filepath.Join(outDir, "kdcreq.pem"),
-		"-subj", fmt.Sprintf("/C=%s/ST=%s/L=%s/O=%s/OU=%s/CN=%s/emailAddress=%s", country, stateProvince, locality, orgName, unit, commonName, email))
-	data, err := cmd.CombinedOutput()
-	if err != nil {
-		return trace.Wrap(err)
+// DiscoverKDCs resolves _kerberos._tcp.<realm> and _kerberos-adm._tcp.<realm> SRV records for
+// every realm in k.realmConfigs() and replaces k.Realms with the result, the same DNS-based
+// discovery gokrb5's dnsutils package performs for a client that doesn't want to hardcode KDC
+// addresses in krb5.conf. It also points KDCHostName/AdminServerName (used by the PKINIT AS
+// exchange itself, see pkinit_asreq.go) at the first discovered realm's first KDC/admin server.
+func (k *KInit) DiscoverKDCs(ctx context.Context) error {
+	realms := k.realmConfigs()
+	discovered := make([]RealmConfig, len(realms))
+
+	for i, realm := range realms {
+		kdcs, err := lookupSRVHosts(ctx, "kerberos", realm.Name)
+		if err != nil {
+			return fmt.Errorf("discovering KDCs for realm %s: %w", realm.Name, err)
+		}
+		admins, err := lookupSRVHosts(ctx, "kerberos-adm", realm.Name)
+		if err != nil {
+			return fmt.Errorf("discovering admin servers for realm %s: %w", realm.Name, err)
+		}
+		discovered[i] = RealmConfig{Name: realm.Name, KDCHosts: kdcs, AdminServers: admins}
 	}
-	k.Log.Debug(string(data))
 
-	// env REALM=ALISTANIS.GITHUB.BETA.TAILSCALE.NET openssl x509 -req -in kdc.req \\n    -CAkey cakey.pem -CA cacert.pem -out kdc.pem -days 365 \\n    -extfile extensions.kdc -extensions kdc_cert -CAcreateserial
-	cmd = exec.CommandContext(ctx,
-		"openssl", "x509", "-req", "-in", filepath.Join(outDir, "kdcreq.pem"),
-		"-CAkey", k.CAKeyPath,
-		"-CA", k.CACertPath,
-		"-out", filepath.Join(outDir, "kdc.pem"),
-		"-days", "3650",
-		"-extfile", extensionsFile,
-		"-extensions", "kdc_cert",
-		"-CACreateserial",
-	)
-
-	cmd.Env = append(cmd.Env, []string{fmt.Sprintf("REALM=%s", k.RealmName)}...)
-	data, err = cmd.CombinedOutput()
-	if err != nil {
-		return trace.Wrap(err)
+	k.Realms = discovered
+	if len(discovered) > 0 {
+		k.RealmName = discovered[0].Name
+		if len(discovered[0].KDCHosts) > 0 {
+			k.KDCHostName = discovered[0].KDCHosts[0]
+		}
+		if len(discovered[0].AdminServers) > 0 {
+			k.AdminServerName = discovered[0].AdminServers[0]
+		}
 	}
-	k.Log.Debug(string(data))
-
 	return nil
 }
 
-// GenerateClientCertKey generates a client certificate and key pair for use with Kerberos x509 authentication
-func (k *KInit) GenerateClientCertKey(ctx context.Context, extensionsFile, country, stateProvince, locality, orgName, unit, commonName, email, outDir string) error {
-	keyName := fmt.Sprintf("%s-key.pem", commonName)
-	reqName := fmt.Sprintf("%s-req.pem", commonName)
-	certName := fmt.Sprintf("%s-cert.pem", commonName)
-
-	keyPath := filepath.Join(outDir, keyName)
-	reqPath := filepath.Join(outDir, reqName)
-	certPath := filepath.Join(outDir, certName)
-
-	cmd := exec.CommandContext(ctx,
-		"openssl", "req", "-newkey", "rsa:4096", "-sha256", "-nodes",
-		"-keyout", keyPath,
-		"-out", reqPath,
-		"-subj", fmt.Sprintf("/C=%s/ST=%s/L=%s/O=%s/OU=%s/CN=%s/emailAddress=%s", country, stateProvince, locality, orgName, unit, commonName, email))
-	data, err := cmd.CombinedOutput()
+// lookupSRVHosts resolves _service._tcp.realm and returns the target hosts, ordered as
+// net.LookupSRV already sorts them (by priority, then weight).
+func lookupSRVHosts(ctx context.Context, service, realm string) ([]string, error) {
+	_, addrs, err := net.DefaultResolver.LookupSRV(ctx, service, "tcp", realm)
 	if err != nil {
-		return trace.Wrap(err)
+		return nil, err
 	}
-	k.Log.Debug(string(data))
-
-	// env REALM=ALISTANIS.GITHUB.BETA.TAILSCALE.NET; export REALM; CLIENT=chris; export CLIENT; openssl x509 -CAkey cakey.pem -CA cacert.pem -req -in client.req -extensions client_cert -extfile extensions.kdc  -out client.pem\n
-	cmd = exec.CommandContext(ctx,
-		"openssl", "x509", "-req", "-in", reqPath,
-		"-CAkey", k.CAKeyPath,
-		"-CA", k.CACertPath,
-		"-out", certPath,
-		"-days", "3650",
-		"-extfile", extensionsFile,
-		"-extensions", "client_cert",
-		"-CACreateserial",
-	)
-
-	cmd.Env = append(cmd.Env, []string{fmt.Sprintf("REALM=%s", k.RealmName), fmt.Sprintf("CLIENT=%s", commonName)}...)
-	data, err = cmd.CombinedOutput()
-	if err != nil {
-		return trace.Wrap(err)
+	hosts := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		hosts = append(hosts, strings.TrimSuffix(addr.Target, "."))
 	}
-	k.Log.Debug(string(data))
-
-	return nil
+	return hosts, nil
 }
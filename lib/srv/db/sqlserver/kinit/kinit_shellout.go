@@ -0,0 +1,202 @@
+//go:build pkinit_shellout
+
+// Copyright 2022 Gravitational, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file is the pre-PKINIT implementation that shells out to openssl and kinit. It's kept
+// around behind the pkinit_shellout build tag as a fallback for hosts where the in-process
+// implementation in pkinit_cert.go and pkinit_asreq.go hasn't been validated yet; it requires MIT Kerberos and
+// openssl to be installed alongside the Teleport binary.
+package kinit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/gravitational/trace"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+)
+
+const kdcExtensionsFileText = `[ kdc_cert ]
+basicConstraints=CA:FALSE
+
+# Here are some examples of the usage of nsCertType. If it is omitted
+keyUsage = nonRepudiation, digitalSignature, keyEncipherment, keyAgreement
+
+#Pkinit EKU
+extendedKeyUsage = 1.3.6.1.5.2.3.5
+
+subjectKeyIdentifier=hash
+authorityKeyIdentifier=keyid,issuer
+
+# Copy subject details
+
+issuerAltName=issuer:copy
+
+# Add id-pkinit-san (pkinit subjectAlternativeName)
+subjectAltName=otherName:1.3.6.1.5.2.2;SEQUENCE:kdc_princ_name
+
+[kdc_princ_name]
+realm = EXP:0, GeneralString:${ENV::REALM}
+principal_name = EXP:1, SEQUENCE:kdc_principal_seq
+
+[kdc_principal_seq]
+name_type = EXP:0, INTEGER:1
+name_string = EXP:1, SEQUENCE:kdc_principals
+
+[kdc_principals]
+princ1 = GeneralString:krbtgt
+princ2 = GeneralString:${ENV::REALM}
+
+[ client_cert ]
+
+# These extensions are added when 'ca' signs a request.
+
+basicConstraints=CA:FALSE
+
+keyUsage = digitalSignature, keyEncipherment, keyAgreement
+
+extendedKeyUsage =  1.3.6.1.5.2.3.4
+subjectKeyIdentifier=hash
+authorityKeyIdentifier=keyid,issuer
+
+
+subjectAltName=otherName:1.3.6.1.5.2.2;SEQUENCE:princ_name
+
+
+# Copy subject details
+
+issuerAltName=issuer:copy
+
+[princ_name]
+realm = EXP:0, GeneralString:${ENV::REALM}
+principal_name = EXP:1, SEQUENCE:principal_seq
+
+[principal_seq]
+name_type = EXP:0, INTEGER:1
+name_string = EXP:1, SEQUENCE:principals
+
+[principals]
+princ1 = GeneralString:${ENV::CLIENT}`
+
+// CreateOrAppendCredentialsCache creates or appends to an existing credentials cache. There must be a valid KDC running
+// at the specified certificate authority address as defined in the CA Certificate
+func (k *KInit) CreateOrAppendCredentialsCache(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx,
+		"kinit",
+		"-X", fmt.Sprintf("X509_anchors=FILE:%s", k.CACertPath),
+		"-X", fmt.Sprintf("X509_user_identity=FILE:%s,%s", k.UserCertPath, k.UserKeyPath), k.UserName,
+		"-c", k.CacheName)
+	data, err := cmd.CombinedOutput()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	// todo better error handling from output/fully wrap libkrb5 for linux
+	k.Log.Debug(string(data))
+
+	// Load what kinit wrote so CCache serves this path the same way as the in-process one.
+	cache, err := credentials.LoadCCache(k.CacheName)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	k.cache = cache
+	return nil
+}
+
+// GenerateKDCExtensions file for openssl
+func (k *KInit) GenerateKDCExtensions(path string) error {
+	return os.WriteFile(path, []byte(kdcExtensionsFileText), 0644)
+}
+
+// GenerateKDCCertKey generates an intermediary certificate and key pair specifically for a Kerberos Key Distribution Center
+func (k *KInit) GenerateKDCCertKey(ctx context.Context, extensionsFile, country, stateProvince, locality, orgName, unit, commonName, email, outDir string) error {
+	cmd := exec.CommandContext(ctx,
+		"openssl", "req", "-newkey", "rsa:4096", "-sha256", "-nodes",
+		"-keyout", filepath.Join(outDir, "kdckey.pem"),
+		"-out", filepath.Join(outDir, "kdcreq.pem"),
+		"-subj", fmt.Sprintf("/C=%s/ST=%s/L=%s/O=%s/OU=%s/CN=%s/emailAddress=%s", country, stateProvince, locality, orgName, unit, commonName, email))
+	data, err := cmd.CombinedOutput()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	k.Log.Debug(string(data))
+
+	cmd = exec.CommandContext(ctx,
+		"openssl", "x509", "-req", "-in", filepath.Join(outDir, "kdcreq.pem"),
+		"-CAkey", k.CAKeyPath,
+		"-CA", k.CACertPath,
+		"-out", filepath.Join(outDir, "kdc.pem"),
+		"-days", "3650",
+		"-extfile", extensionsFile,
+		"-extensions", "kdc_cert",
+		"-CACreateserial",
+	)
+
+	cmd.Env = append(cmd.Env, []string{fmt.Sprintf("REALM=%s", k.RealmName)}...)
+	data, err = cmd.CombinedOutput()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	k.Log.Debug(string(data))
+
+	return nil
+}
+
+// GenerateClientCertKey generates a client certificate and key pair for use with Kerberos x509
+// authentication. provider is ignored here: the shell-out path always has openssl mint its own
+// key straight to keyPath on disk, unlike the in-process implementation in pkinit_cert.go, which
+// needs a KeyProvider because it never writes the private key to disk at all.
+func (k *KInit) GenerateClientCertKey(ctx context.Context, provider KeyProvider, extensionsFile, country, stateProvince, locality, orgName, unit, commonName, email, outDir string) error {
+	keyName := fmt.Sprintf("%s-key.pem", commonName)
+	reqName := fmt.Sprintf("%s-req.pem", commonName)
+	certName := fmt.Sprintf("%s-cert.pem", commonName)
+
+	keyPath := filepath.Join(outDir, keyName)
+	reqPath := filepath.Join(outDir, reqName)
+	certPath := filepath.Join(outDir, certName)
+
+	cmd := exec.CommandContext(ctx,
+		"openssl", "req", "-newkey", "rsa:4096", "-sha256", "-nodes",
+		"-keyout", keyPath,
+		"-out", reqPath,
+		"-subj", fmt.Sprintf("/C=%s/ST=%s/L=%s/O=%s/OU=%s/CN=%s/emailAddress=%s", country, stateProvince, locality, orgName, unit, commonName, email))
+	data, err := cmd.CombinedOutput()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	k.Log.Debug(string(data))
+
+	cmd = exec.CommandContext(ctx,
+		"openssl", "x509", "-req", "-in", reqPath,
+		"-CAkey", k.CAKeyPath,
+		"-CA", k.CACertPath,
+		"-out", certPath,
+		"-days", "3650",
+		"-extfile", extensionsFile,
+		"-extensions", "client_cert",
+		"-CACreateserial",
+	)
+
+	cmd.Env = append(cmd.Env, []string{fmt.Sprintf("REALM=%s", k.RealmName), fmt.Sprintf("CLIENT=%s", commonName)}...)
+	data, err = cmd.CombinedOutput()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	k.Log.Debug(string(data))
+
+	return nil
+}
@@ -0,0 +1,646 @@
+//go:build !pkinit_shellout
+
+// Copyright 2022 Gravitational, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinit
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"time"
+
+	"github.com/gravitational/trace"
+	gokrb5crypto "github.com/jcmturner/gokrb5/v8/crypto"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/iana/errorcode"
+	"github.com/jcmturner/gokrb5/v8/iana/etypeID"
+	"github.com/jcmturner/gokrb5/v8/iana/keyusage"
+	"github.com/jcmturner/gokrb5/v8/iana/msgtype"
+	"github.com/jcmturner/gokrb5/v8/iana/nametype"
+	"github.com/jcmturner/gokrb5/v8/iana/patype"
+	"github.com/jcmturner/gokrb5/v8/messages"
+	"github.com/jcmturner/gokrb5/v8/types"
+)
+
+// This file implements the client side of RFC 4556 PKINIT: it signs and sends the AS-REQ,
+// then parses the PA-PK-AS-REP padata out of the AS-REP to recover the reply key and build an
+// in-memory credentials cache.
+//
+// Scope: we implement the "win2k"/RSA key-transport variant of PA-PK-AS-REP, where the KDC
+// wraps the reply key in a CMS EnvelopedData addressed to our client certificate's RSA key,
+// itself wrapped in a SignedData the KDC signs - what AD KDCs default to. The Diffie-Hellman
+// variant (dhSignedData, used when the KDC wants perfect forward secrecy) isn't implemented;
+// buildASReq's nonce and authPack are the extension point for the clientPublicValue/
+// clientDHNonce fields that path needs.
+//
+// Before the reply key is trusted, verifyKDCSignedData checks the SignedData's signature and
+// that its certificate chains to the configured CA and carries the id-pkinit-KPKdc EKU -
+// otherwise anything that can intercept the plaintext AS exchange on port 88 could hand back an
+// arbitrary reply key.
+
+const (
+	// id-pkinit-authData, the eContentType of the SignedData wrapping an AuthPack.
+	pkinitAuthDataOID = "1.3.6.1.5.2.3.1"
+	// sha256WithRSAEncryption, used to sign the AuthPack.
+	sha256WithRSAEncryptionOID = "1.2.840.113549.1.1.11"
+	// id-signedData, the CMS ContentInfo contentType for a SignedData.
+	cmsIDSignedDataOID = "1.2.840.113549.1.7.2"
+)
+
+var (
+	// oidSHA256 is the digestAlgorithm OID for SHA-256, used both to sign our own AuthPack and
+	// to verify the KDC's signature over PA-PK-AS-REP.
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	// oidSHA1 is the digestAlgorithm OID for SHA-1, still used by some KDCs to sign
+	// PA-PK-AS-REP.
+	oidSHA1 = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+)
+
+// pkAuthenticator is PKAuthenticator from RFC 4556 section 3.2.1.
+type pkAuthenticator struct {
+	CUSec      int    `asn1:"explicit,tag:0"`
+	CTime      string `asn1:"generalized,explicit,tag:1"`
+	Nonce      int    `asn1:"explicit,tag:2"`
+	PAChecksum []byte `asn1:"optional,explicit,tag:3"`
+}
+
+// authPack is AuthPack from RFC 4556 section 3.2.1, minus the Diffie-Hellman fields we don't
+// populate (see the package doc above).
+type authPack struct {
+	PKAuthenticator pkAuthenticator `asn1:"explicit,tag:0"`
+}
+
+// replyKeyPack is ReplyKeyPack from RFC 4556 section 3.2.3.2: the reply key the KDC wants us
+// to use to decrypt the AS-REP enc-part, plus a checksum over the request we sent.
+type replyKeyPack struct {
+	ReplyKey   encryptionKeyASN1 `asn1:"explicit,tag:0"`
+	ASChecksum checksumASN1      `asn1:"explicit,tag:1"`
+}
+
+type encryptionKeyASN1 struct {
+	KeyType  int    `asn1:"explicit,tag:0"`
+	KeyValue []byte `asn1:"explicit,tag:1"`
+}
+
+type checksumASN1 struct {
+	CksumType int    `asn1:"explicit,tag:0"`
+	Checksum  []byte `asn1:"explicit,tag:1"`
+}
+
+// Minimal CMS (RFC 5652) ASN.1 subset: just enough to build a one-signer SignedData and parse
+// a one-recipient EnvelopedData, which is all a single PKINIT exchange needs.
+type cmsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type cmsEncapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     asn1.RawValue `asn1:"optional,explicit,tag:0"`
+}
+
+type cmsIssuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type cmsSignerInfo struct {
+	Version            int
+	Sid                cmsIssuerAndSerialNumber
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type cmsSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo cmsEncapsulatedContentInfo
+	Certificates     asn1.RawValue   `asn1:"optional,explicit,tag:0"`
+	SignerInfos      []cmsSignerInfo `asn1:"set"`
+}
+
+type cmsRecipientInfo struct {
+	Version                int
+	Rid                    cmsIssuerAndSerialNumber
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+type cmsEnvelopedData struct {
+	Version              int
+	RecipientInfos       []cmsRecipientInfo `asn1:"set"`
+	EncryptedContentInfo cmsEncryptedContentInfo
+}
+
+type cmsEncryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           []byte `asn1:"optional,implicit,tag:0"`
+}
+
+func parseOID(s string) (asn1.ObjectIdentifier, error) {
+	var oid asn1.ObjectIdentifier
+	cur := 0
+	started := false
+	for _, r := range s {
+		if r == '.' {
+			oid = append(oid, cur)
+			cur = 0
+			started = false
+			continue
+		}
+		if r < '0' || r > '9' {
+			return nil, trace.BadParameter("invalid OID %q", s)
+		}
+		cur = cur*10 + int(r-'0')
+		started = true
+	}
+	if started {
+		oid = append(oid, cur)
+	}
+	return oid, nil
+}
+
+func mustParseOID(s string) asn1.ObjectIdentifier {
+	oid, err := parseOID(s)
+	if err != nil {
+		panic(err)
+	}
+	return oid
+}
+
+// signedAuthPack builds the PA-PK-AS-REQ padata value: a CMS SignedData, eContentType
+// id-pkinit-authData, enclosing the DER-encoded AuthPack and signed through provider.
+func signedAuthPack(ctx context.Context, cert *x509.Certificate, provider KeyProvider, nonce int) ([]byte, error) {
+	now := time.Now().UTC()
+	content, err := asn1.Marshal(authPack{
+		PKAuthenticator: pkAuthenticator{
+			CUSec: now.Nanosecond() / 1000,
+			CTime: now.Format("20060102150405Z"),
+			Nonce: nonce,
+		},
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	digest := sha256.Sum256(content)
+	sig, err := provider.SignPKINIT(ctx, digest[:], crypto.SHA256)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	signed := cmsSignedData{
+		Version: 3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{
+			{Algorithm: oidSHA256},
+		},
+		EncapContentInfo: cmsEncapsulatedContentInfo{
+			EContentType: mustParseOID(pkinitAuthDataOID),
+			EContent:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: content},
+		},
+		Certificates: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: cert.Raw},
+		SignerInfos: []cmsSignerInfo{{
+			Version: 1,
+			Sid: cmsIssuerAndSerialNumber{
+				Issuer:       asn1.RawValue{FullBytes: cert.RawIssuer},
+				SerialNumber: cert.SerialNumber,
+			},
+			DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: mustParseOID(sha256WithRSAEncryptionOID)},
+			Signature:          sig,
+		}},
+	}
+
+	signedDER, err := asn1.Marshal(signed)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	ci, err := asn1.Marshal(cmsContentInfo{
+		ContentType: mustParseOID(cmsIDSignedDataOID),
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedDER},
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return ci, nil
+}
+
+// buildASReq assembles a KRB-AS-REQ carrying the PA-PK-AS-REQ padata signedAuthPackDER.
+func buildASReq(clientRealm string, clientName, serverName []string, nonce int, signedAuthPackDER []byte) messages.ASReq {
+	now := time.Now().UTC()
+	body := messages.KDCReqBody{
+		CName:      types.PrincipalName{NameType: nametype.KRB_NT_PRINCIPAL, NameString: clientName},
+		Realm:      clientRealm,
+		SName:      types.PrincipalName{NameType: nametype.KRB_NT_SRV_INST, NameString: serverName},
+		Till:       now.Add(24 * time.Hour),
+		Nonce:      nonce,
+		EType:      []int32{etypeID.AES256_CTS_HMAC_SHA1_96, etypeID.AES128_CTS_HMAC_SHA1_96},
+		KDCOptions: asn1.BitString{Bytes: []byte{0x40, 0, 0, 0}, BitLength: 32}, // forwardable
+	}
+
+	return messages.ASReq{
+		PVNO:    5,
+		MsgType: msgtype.KRB_AS_REQ,
+		PAData: types.PADataSequence{
+			types.PAData{PADataType: patype.PA_PK_AS_REQ, PADataValue: signedAuthPackDER},
+		},
+		ReqBody: body,
+	}
+}
+
+// sendASReq sends req to kdcAddr (host:88), trying UDP first and falling back to TCP for
+// large responses, and unmarshals the reply as an AS-REP.
+func sendASReq(deadline time.Time, kdcAddr string, req messages.ASReq) (messages.ASRep, error) {
+	reqDER, err := req.Marshal()
+	if err != nil {
+		return messages.ASRep{}, trace.Wrap(err)
+	}
+
+	respDER, err := exchangeUDP(deadline, kdcAddr, reqDER)
+	if err == nil {
+		// A KDC whose reply doesn't fit in a datagram answers with KRB_ERR_RESPONSE_TOO_BIG.
+		var krbErr messages.KRBError
+		if krbErr.Unmarshal(respDER) == nil && krbErr.ErrorCode == errorcode.KRB_ERR_RESPONSE_TOO_BIG {
+			err = trace.LimitExceeded("KDC reply too big for UDP")
+		}
+	}
+	if err != nil {
+		respDER, err = exchangeTCP(deadline, kdcAddr, reqDER)
+		if err != nil {
+			return messages.ASRep{}, trace.Wrap(err)
+		}
+	}
+
+	var asRep messages.ASRep
+	if err := asRep.Unmarshal(respDER); err != nil {
+		var krbErr messages.KRBError
+		if uerr := krbErr.Unmarshal(respDER); uerr == nil {
+			return messages.ASRep{}, trace.Wrap(krbErr)
+		}
+		return messages.ASRep{}, trace.Wrap(err)
+	}
+	return asRep, nil
+}
+
+func exchangeUDP(deadline time.Time, addr string, req []byte) ([]byte, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	if _, err := conn.Write(req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	buf := make([]byte, 65507)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return buf[:n], nil
+}
+
+func exchangeTCP(deadline time.Time, addr string, req []byte) ([]byte, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	// RFC 4120 section 7.2.2: TCP messages are length-prefixed with a 4-byte big-endian length.
+	reqLen := len(req)
+	lenPrefix := []byte{byte(reqLen >> 24), byte(reqLen >> 16), byte(reqLen >> 8), byte(reqLen)}
+	if _, err := conn.Write(append(lenPrefix, req...)); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var respLenPrefix [4]byte
+	if _, err := io.ReadFull(conn, respLenPrefix[:]); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	respLen := int(respLenPrefix[0])<<24 | int(respLenPrefix[1])<<16 | int(respLenPrefix[2])<<8 | int(respLenPrefix[3])
+	resp := make([]byte, respLen)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return resp, nil
+}
+
+// replyKeyFromASRep extracts the PA-PK-AS-REP padata from asRep and unwraps the reply key the
+// KDC encrypted to our client certificate, per the RSA key-transport path described in the
+// package doc above. The padata is a SignedData, not a bare EnvelopedData: before trusting the
+// reply key inside, we verify the KDC's signature over it and that the signing certificate
+// chains to trustRoots and carries the id-pkinit-KPKdc EKU - otherwise anything that can
+// intercept the plaintext AS exchange on port 88 could hand back an arbitrary reply key.
+func replyKeyFromASRep(ctx context.Context, asRep messages.ASRep, provider KeyProvider, trustRoots *x509.CertPool) (types.EncryptionKey, error) {
+	var pkRepDER []byte
+	for _, pad := range asRep.PAData {
+		if pad.PADataType == patype.PA_PK_AS_REP {
+			pkRepDER = pad.PADataValue
+			break
+		}
+	}
+	if pkRepDER == nil {
+		return types.EncryptionKey{}, trace.NotFound("AS-REP did not include PA-PK-AS-REP padata")
+	}
+
+	var ci cmsContentInfo
+	if _, err := asn1.Unmarshal(pkRepDER, &ci); err != nil {
+		return types.EncryptionKey{}, trace.Wrap(err)
+	}
+	var signed cmsSignedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &signed); err != nil {
+		return types.EncryptionKey{}, trace.Wrap(err)
+	}
+	envelopedDER, err := verifyKDCSignedData(signed, trustRoots)
+	if err != nil {
+		return types.EncryptionKey{}, trace.Wrap(err)
+	}
+
+	var enveloped cmsEnvelopedData
+	if _, err := asn1.Unmarshal(envelopedDER, &enveloped); err != nil {
+		return types.EncryptionKey{}, trace.Wrap(err)
+	}
+	if len(enveloped.RecipientInfos) == 0 {
+		return types.EncryptionKey{}, trace.BadParameter("PA-PK-AS-REP EnvelopedData has no recipients")
+	}
+
+	// Unwrap the content-encryption key through provider (RSA PKCS#1v1.5 key transport).
+	cek, err := provider.Decrypt(ctx, enveloped.RecipientInfos[0].EncryptedKey)
+	if err != nil {
+		return types.EncryptionKey{}, trace.Wrap(err)
+	}
+
+	plainPack, err := decryptAESCBC(cek, enveloped.EncryptedContentInfo.EncryptedContent)
+	if err != nil {
+		return types.EncryptionKey{}, trace.Wrap(err)
+	}
+
+	var pack replyKeyPack
+	if _, err := asn1.Unmarshal(plainPack, &pack); err != nil {
+		return types.EncryptionKey{}, trace.Wrap(err)
+	}
+
+	return types.EncryptionKey{
+		KeyType:  pack.ReplyKey.KeyType,
+		KeyValue: pack.ReplyKey.KeyValue,
+	}, nil
+}
+
+// verifyKDCSignedData checks that signed's SignerInfo is a valid signature, by a certificate
+// chaining to trustRoots and carrying the id-pkinit-KPKdc EKU, over signed's encapsulated
+// content, returning that content (the DER-encoded EnvelopedData carrying the reply key) once
+// verified.
+//
+// This only checks the chain and the EKU, not the KDC certificate's subject against
+// KDCHostName the way TLS server validation would: RFC 4556 doesn't define that binding, so
+// the chain plus the KDC EKU is what distinguishes a KDC certificate from any other certificate
+// trustRoots happens to have issued.
+func verifyKDCSignedData(signed cmsSignedData, trustRoots *x509.CertPool) ([]byte, error) {
+	if len(signed.SignerInfos) == 0 {
+		return nil, trace.BadParameter("PA-PK-AS-REP SignedData has no SignerInfos")
+	}
+	signerInfo := signed.SignerInfos[0]
+
+	certs, err := x509.ParseCertificates(signed.Certificates.Bytes)
+	if err != nil {
+		return nil, trace.Wrap(err, "parsing KDC certificate from PA-PK-AS-REP")
+	}
+	if len(certs) == 0 {
+		return nil, trace.BadParameter("PA-PK-AS-REP SignedData did not include a KDC certificate")
+	}
+	kdcCert := certs[0]
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	if _, err := kdcCert.Verify(x509.VerifyOptions{
+		Roots:         trustRoots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, trace.Wrap(err, "validating KDC certificate chain")
+	}
+	if !certHasPKINITKdcEKU(kdcCert) {
+		return nil, trace.BadParameter("KDC certificate does not carry the id-pkinit-KPKdc EKU")
+	}
+
+	hash, err := digestAlgorithmHash(signerInfo.DigestAlgorithm)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	hasher := hash.New()
+	hasher.Write(signed.EncapContentInfo.EContent.Bytes)
+	digest := hasher.Sum(nil)
+
+	rsaPub, ok := kdcCert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, trace.BadParameter("KDC certificate public key is %T, expected RSA", kdcCert.PublicKey)
+	}
+	if err := rsa.VerifyPKCS1v15(rsaPub, hash, digest, signerInfo.Signature); err != nil {
+		return nil, trace.Wrap(err, "verifying KDC signature over PA-PK-AS-REP")
+	}
+
+	return signed.EncapContentInfo.EContent.Bytes, nil
+}
+
+// certHasPKINITKdcEKU reports whether cert carries the id-pkinit-KPKdc EKU (oidPKINITKPKdc,
+// defined in pkinit_cert.go). Go's x509 package doesn't recognize this OID, so it surfaces in
+// UnknownExtKeyUsage rather than the typed ExtKeyUsage slice.
+func certHasPKINITKdcEKU(cert *x509.Certificate) bool {
+	for _, oid := range cert.UnknownExtKeyUsage {
+		if oid.Equal(oidPKINITKPKdc) {
+			return true
+		}
+	}
+	return false
+}
+
+// digestAlgorithmHash maps a CMS DigestAlgorithm OID to the crypto.Hash PA-PK-AS-REP's
+// SignerInfo signed with.
+func digestAlgorithmHash(alg pkix.AlgorithmIdentifier) (crypto.Hash, error) {
+	switch {
+	case alg.Algorithm.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case alg.Algorithm.Equal(oidSHA1):
+		return crypto.SHA1, nil
+	default:
+		return 0, trace.BadParameter("unsupported PA-PK-AS-REP digest algorithm %s", alg.Algorithm)
+	}
+}
+
+func decryptAESCBC(key, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(ciphertext) < aes.BlockSize || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, trace.BadParameter("ciphertext is not a valid multiple of the AES block size")
+	}
+	iv := ciphertext[:aes.BlockSize]
+	body := ciphertext[aes.BlockSize:]
+	out := make([]byte, len(body))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, body)
+	return unpadPKCS7(out)
+}
+
+func unpadPKCS7(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, trace.BadParameter("empty plaintext")
+	}
+	padLen := int(b[len(b)-1])
+	if padLen <= 0 || padLen > len(b) {
+		return nil, trace.BadParameter("invalid PKCS#7 padding")
+	}
+	return b[:len(b)-padLen], nil
+}
+
+func readPEMFile(path, blockType string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, trace.BadParameter("%s does not contain a PEM %s", path, blockType)
+	}
+	return block.Bytes, nil
+}
+
+// kdcTrustRoots loads k.CACertPath as the trust anchor PA-PK-AS-REP's KDC certificate must
+// chain to. It's the same CA that issues our own client certificate (see GenerateClientCertKey
+// in pkinit_cert.go): in a PKINIT deployment the domain's Enterprise CA issues certificates for
+// both directions, so there's no separate "KDC CA" to configure.
+func (k *KInit) kdcTrustRoots() (*x509.CertPool, error) {
+	caCertPEM, err := os.ReadFile(k.CACertPath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCertPEM) {
+		return nil, trace.BadParameter("%s does not contain a valid PEM certificate", k.CACertPath)
+	}
+	return pool, nil
+}
+
+// CreateOrAppendCredentialsCache runs the PKINIT AS exchange for the user identified by
+// k.UserCertPath and k.keyProvider against k.KDCHostName, and stores the resulting credentials
+// cache in memory (see KInit.CCache) instead of writing it to k.CacheName on disk.
+func (k *KInit) CreateOrAppendCredentialsCache(ctx context.Context) error {
+	if k.keyProvider == nil {
+		return trace.BadParameter("no client key provider available, GenerateClientCertKey must be called first")
+	}
+
+	certDER, err := readPEMFile(k.UserCertPath, "CERTIFICATE")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	var nonceBytes [4]byte
+	if _, err := rand.Read(nonceBytes[:]); err != nil {
+		return trace.Wrap(err)
+	}
+	nonce := int(nonceBytes[0])<<24 | int(nonceBytes[1])<<16 | int(nonceBytes[2])<<8 | int(nonceBytes[3])
+
+	signedPack, err := signedAuthPack(ctx, cert, k.keyProvider, nonce)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	clientName := []string{k.UserName}
+	serverName := []string{"krbtgt", k.RealmName}
+	asReq := buildASReq(k.RealmName, clientName, serverName, nonce, signedPack)
+
+	deadline := time.Now().Add(15 * time.Second)
+	if d, ok := ctx.Deadline(); ok {
+		deadline = d
+	}
+	asRep, err := sendASReq(deadline, fmt.Sprintf("%s:88", k.KDCHostName), asReq)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	trustRoots, err := k.kdcTrustRoots()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	replyKey, err := replyKeyFromASRep(ctx, asRep, k.keyProvider, trustRoots)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	encPartBytes, err := gokrb5crypto.DecryptEncPart(asRep.EncPart, replyKey, keyusage.AS_REP_ENCPART)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var encPart messages.EncASRepPart
+	if err := encPart.Unmarshal(encPartBytes); err != nil {
+		return trace.Wrap(err)
+	}
+	// RFC 4120 §3.1.3: the nonce the KDC echoes back must match the one we sent, or this AS-REP
+	// could be a stale reply replayed by something that recorded an earlier valid exchange.
+	if encPart.Nonce != nonce {
+		return trace.AccessDenied("AS-REP nonce %d does not match the request nonce %d", encPart.Nonce, nonce)
+	}
+
+	clientPrincipal := types.PrincipalName{NameType: nametype.KRB_NT_PRINCIPAL, NameString: clientName}
+	ticketBytes, err := asRep.Ticket.Marshal()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	k.cache = &credentials.CCache{
+		Version:          4,
+		DefaultPrincipal: credentials.Principal{Realm: k.RealmName, PrincipalName: clientPrincipal},
+		Credentials: []*credentials.Credential{{
+			Client:      credentials.Principal{Realm: k.RealmName, PrincipalName: clientPrincipal},
+			Server:      credentials.Principal{Realm: asRep.Ticket.Realm, PrincipalName: asRep.Ticket.SName},
+			Key:         encPart.Key,
+			AuthTime:    encPart.AuthTime,
+			StartTime:   encPart.StartTime,
+			EndTime:     encPart.EndTime,
+			RenewTill:   encPart.RenewTill,
+			TicketFlags: encPart.Flags,
+			Ticket:      ticketBytes,
+		}},
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+//go:build !pkinit_shellout
+
+// Copyright 2022 Gravitational, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinit
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+var (
+	// oidPKINITSAN is id-pkinit-san, the otherName type-id carrying a KRB5PrincipalName in a
+	// certificate's subjectAltName (RFC 4556 section 3.2.2).
+	oidPKINITSAN = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 2, 2}
+	// oidPKINITKPClientAuth is id-pkinit-KPClientAuth, the EKU a PKINIT client certificate must
+	// carry.
+	oidPKINITKPClientAuth = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 2, 3, 4}
+	// oidPKINITKPKdc is id-pkinit-KPKdc, the EKU a PKINIT KDC certificate must carry.
+	oidPKINITKPKdc = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 2, 3, 5}
+	// oidSubjectAltName is the standard X.509 subjectAltName extension OID.
+	oidSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+	// oidEmailAddress lets us set the legacy PKCS#9 emailAddress RDN that the openssl -subj
+	// flag used to populate, so certificate Subjects look the same either way.
+	oidEmailAddress = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}
+)
+
+// principalName is the PrincipalName sequence from RFC 4120 section 5.2.2, used inside a
+// KRB5PrincipalName otherName SAN.
+type principalName struct {
+	NameType   int      `asn1:"explicit,tag:0"`
+	NameString []string `asn1:"generalstring,explicit,tag:1"`
+}
+
+// krb5PrincipalName is the KRB5PrincipalName otherName value from RFC 4556 section 3.2.2.
+type krb5PrincipalName struct {
+	Realm         string        `asn1:"generalstring,explicit,tag:0"`
+	PrincipalName principalName `asn1:"explicit,tag:1"`
+}
+
+// pkinitSANExtension builds the subjectAltName extension value for a PKINIT certificate: a
+// single GeneralName of type otherName, holding a KRB5PrincipalName tagged with oidPKINITSAN.
+func pkinitSANExtension(realm string, nameType int, nameString []string) (pkix.Extension, error) {
+	princName, err := asn1.Marshal(krb5PrincipalName{
+		Realm: realm,
+		PrincipalName: principalName{
+			NameType:   nameType,
+			NameString: nameString,
+		},
+	})
+	if err != nil {
+		return pkix.Extension{}, trace.Wrap(err)
+	}
+
+	// OtherName ::= SEQUENCE { type-id OBJECT IDENTIFIER, value [0] EXPLICIT ANY DEFINED BY type-id }
+	otherNameSeq, err := asn1.Marshal(struct {
+		TypeID asn1.ObjectIdentifier
+		Value  asn1.RawValue `asn1:"explicit,tag:0"`
+	}{
+		TypeID: oidPKINITSAN,
+		Value:  asn1.RawValue{FullBytes: princName},
+	})
+	if err != nil {
+		return pkix.Extension{}, trace.Wrap(err)
+	}
+
+	// GeneralName's otherName choice is [0] IMPLICIT, which for a SEQUENCE keeps the
+	// constructed encoding but swaps the universal SEQUENCE tag for a context-specific one:
+	// re-parse to strip the outer SEQUENCE header and re-tag the content.
+	var rawOtherName asn1.RawValue
+	if _, err := asn1.Unmarshal(otherNameSeq, &rawOtherName); err != nil {
+		return pkix.Extension{}, trace.Wrap(err)
+	}
+	generalName := asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: rawOtherName.Bytes}
+
+	sanValue, err := asn1.Marshal([]asn1.RawValue{generalName})
+	if err != nil {
+		return pkix.Extension{}, trace.Wrap(err)
+	}
+
+	return pkix.Extension{Id: oidSubjectAltName, Critical: false, Value: sanValue}, nil
+}
+
+// certSubject builds a pkix.Name matching the fields the openssl -subj flag used to set,
+// including the emailAddress RDN that pkix.Name has no dedicated field for.
+func certSubject(country, stateProvince, locality, orgName, unit, commonName, email string) pkix.Name {
+	return pkix.Name{
+		Country:            []string{country},
+		Province:           []string{stateProvince},
+		Locality:           []string{locality},
+		Organization:       []string{orgName},
+		OrganizationalUnit: []string{unit},
+		CommonName:         commonName,
+		ExtraNames: []pkix.AttributeTypeAndValue{
+			{Type: oidEmailAddress, Value: email},
+		},
+	}
+}
+
+// signCertificatePub issues a certificate for template carrying pub as its public key, signed
+// by the CA found at caCertPath/caKeyPath. Unlike signCertificate, it doesn't generate or
+// return a private key: the caller already holds (or, for a TPM-resident key, deliberately
+// never holds) the private half.
+func signCertificatePub(template *x509.Certificate, pub crypto.PublicKey, caCertPath, caKeyPath string) (*x509.Certificate, error) {
+	caCertPEM, err := os.ReadFile(caCertPath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	caKeyPEM, err := os.ReadFile(caKeyPath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	caCertBlock, _ := pem.Decode(caCertPEM)
+	if caCertBlock == nil {
+		return nil, trace.BadParameter("%s does not contain a PEM certificate", caCertPath)
+	}
+	caCert, err := x509.ParseCertificate(caCertBlock.Bytes)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	caKeyBlock, _ := pem.Decode(caKeyPEM)
+	if caKeyBlock == nil {
+		return nil, trace.BadParameter("%s does not contain a PEM private key", caKeyPath)
+	}
+	caKey, err := x509.ParsePKCS1PrivateKey(caKeyBlock.Bytes)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(3650 * 24 * time.Hour)
+	template.BasicConstraintsValid = true
+	template.IsCA = false
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, pub, caKey)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return cert, nil
+}
+
+// signCertificate issues a certificate for template, signed by the CA found at caCertPath/caKeyPath.
+func signCertificate(template *x509.Certificate, caCertPath, caKeyPath string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	caCertPEM, err := os.ReadFile(caCertPath)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	caKeyPEM, err := os.ReadFile(caKeyPath)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+
+	caCertBlock, _ := pem.Decode(caCertPEM)
+	if caCertBlock == nil {
+		return nil, nil, trace.BadParameter("%s does not contain a PEM certificate", caCertPath)
+	}
+	caCert, err := x509.ParseCertificate(caCertBlock.Bytes)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+
+	caKeyBlock, _ := pem.Decode(caKeyPEM)
+	if caKeyBlock == nil {
+		return nil, nil, trace.BadParameter("%s does not contain a PEM private key", caKeyPath)
+	}
+	caKey, err := x509.ParsePKCS1PrivateKey(caKeyBlock.Bytes)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 4096)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(3650 * 24 * time.Hour)
+	template.BasicConstraintsValid = true
+	template.IsCA = false
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	return cert, key, nil
+}
+
+func writePEM(certPath string, cert *x509.Certificate, keyPath string, key *rsa.PrivateKey) error {
+	if err := writeCertPEM(certPath, cert); err != nil {
+		return trace.Wrap(err)
+	}
+	keyBytes := x509.MarshalPKCS1PrivateKey(key)
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: keyBytes}), 0600); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// writeCertPEM writes cert alone, for a client certificate whose private key lives behind a
+// KeyProvider instead of a file writePEM could also write.
+func writeCertPEM(certPath string, cert *x509.Certificate) error {
+	return trace.Wrap(os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0644))
+}
+
+// GenerateKDCExtensions is a no-op in the pure-Go implementation: the SAN and EKU it used to
+// write as an openssl config file are now set directly on the x509.Certificate template in
+// GenerateKDCCertKey/GenerateClientCertKey.
+func (k *KInit) GenerateKDCExtensions(path string) error {
+	return nil
+}
+
+// GenerateKDCCertKey generates, in-process, an intermediary certificate and key pair for a
+// Kerberos Key Distribution Center, carrying the id-pkinit-san SAN and id-pkinit-KPKdc EKU.
+// extensionsFile is unused here (kept for signature compatibility with the shelled-out
+// fallback, which needs a path to write its openssl extensions config to).
+func (k *KInit) GenerateKDCCertKey(ctx context.Context, extensionsFile, country, stateProvince, locality, orgName, unit, commonName, email, outDir string) error {
+	san, err := pkinitSANExtension(k.RealmName, 1, []string{"krbtgt", k.RealmName})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	template := &x509.Certificate{
+		Subject:            certSubject(country, stateProvince, locality, orgName, unit, commonName, email),
+		KeyUsage:           x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageKeyAgreement,
+		UnknownExtKeyUsage: []asn1.ObjectIdentifier{oidPKINITKPKdc},
+		ExtraExtensions:    []pkix.Extension{san},
+	}
+
+	cert, key, err := signCertificate(template, k.CACertPath, k.CAKeyPath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(writePEM(filepath.Join(outDir, "kdc.pem"), cert, filepath.Join(outDir, "kdckey.pem"), key))
+}
+
+// GenerateClientCertKey generates, in-process, a client certificate for PKINIT authentication
+// against provider's public key, carrying the id-pkinit-san SAN and id-pkinit-KPClientAuth EKU.
+// provider's private key never needs to be readable off disk - CreateOrAppendCredentialsCache
+// signs and decrypts through it directly, which is what lets a TPMKeyProvider work here instead
+// of just PEMKeyProvider. extensionsFile is unused here; see GenerateKDCCertKey.
+func (k *KInit) GenerateClientCertKey(ctx context.Context, provider KeyProvider, extensionsFile, country, stateProvince, locality, orgName, unit, commonName, email, outDir string) error {
+	san, err := pkinitSANExtension(k.RealmName, 1, []string{commonName})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	template := &x509.Certificate{
+		Subject:            certSubject(country, stateProvince, locality, orgName, unit, commonName, email),
+		KeyUsage:           x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageKeyAgreement,
+		UnknownExtKeyUsage: []asn1.ObjectIdentifier{oidPKINITKPClientAuth},
+		ExtraExtensions:    []pkix.Extension{san},
+	}
+
+	cert, err := signCertificatePub(template, provider.Public(), k.CACertPath, k.CAKeyPath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	k.UserCertPath = filepath.Join(outDir, fmt.Sprintf("%s-cert.pem", commonName))
+	k.keyProvider = provider
+	return trace.Wrap(writeCertPEM(k.UserCertPath, cert))
+}
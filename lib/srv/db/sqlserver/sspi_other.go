@@ -0,0 +1,33 @@
+//go:build !windows
+
+/*
+Copyright 2023 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlserver
+
+import (
+	"context"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/lib/srv/db/common"
+)
+
+// getSSPIAuth is unavailable outside Windows: SSPI is a Windows-only API, so an agent running
+// on Linux/macOS can't honor authModeSSPI.
+func (c *connector) getSSPIAuth(ctx context.Context, sessionCtx *common.Session) (authProvider, error) {
+	return nil, trace.BadParameter("sspi auth mode requires the database agent to run on Windows")
+}
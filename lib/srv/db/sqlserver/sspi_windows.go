@@ -0,0 +1,82 @@
+//go:build windows
+
+/*
+Copyright 2023 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlserver
+
+import (
+	"context"
+
+	"github.com/alexbrainman/sspi"
+	"github.com/alexbrainman/sspi/negotiate"
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/lib/srv/db/common"
+)
+
+// getSSPIAuth acquires an outbound SSPI Negotiate security context for the database's SPN
+// under the agent's own process identity, the Windows-native alternative to exporting a
+// keytab for a service account on hosts that are already domain-joined and trusted by AD.
+func (c *connector) getSSPIAuth(ctx context.Context, sessionCtx *common.Session) (authProvider, error) {
+	cred, err := negotiate.AcquireCurrentUserCredentials()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	secCtx, initToken, err := negotiate.NewClientContext(cred, sessionCtx.Database.GetAD().SPN)
+	if err != nil {
+		cred.Release()
+		return nil, trace.Wrap(err)
+	}
+
+	// The Negotiate package already frames its output as a SPNEGO NegTokenInit, so unlike the
+	// gokrb5 paths in auth.go we don't need to wrap initToken ourselves before handing it back.
+	return &sspiAuth{cred: cred, secCtx: secCtx, initToken: initToken}, nil
+}
+
+// sspiAuth implements SQL Server driver's "auth" interface on top of the Windows SSPI
+// Negotiate package. Unlike krbAuth, whose single gokrb5 AP-REQ completes the handshake in one
+// leg, SSPI Negotiate can require several round trips, so NextBytes feeds each server
+// challenge back into the security context and returns whatever continuation token it
+// produces until the handshake reports itself complete.
+type sspiAuth struct {
+	cred      *sspi.Credentials
+	secCtx    *negotiate.ClientContext
+	initToken []byte
+	done      bool
+}
+
+func (a *sspiAuth) InitialBytes() ([]byte, error) {
+	return a.initToken, nil
+}
+
+func (a *sspiAuth) NextBytes(challenge []byte) ([]byte, error) {
+	if a.done {
+		return nil, nil
+	}
+	authCompleted, token, err := a.secCtx.Update(challenge)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	a.done = authCompleted
+	return token, nil
+}
+
+func (a *sspiAuth) Free() {
+	a.secCtx.Release()
+	a.cred.Release()
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2023 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package resources holds types shared by every Teleport-backed CRD version package
+// (v2, v3, ...), so a new resource kind doesn't have to redeclare the same status/secretRef
+// boilerplate.
+package resources
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Status is embedded in every Teleport-backed CR's Status subresource. It satisfies the
+// StatusConditions accessor resources.TeleportKubernetesResource requires, so a CRD type only
+// has to embed it instead of redeclaring Conditions and its accessor.
+type Status struct {
+	// Conditions represent the latest available observations of the resource's state.
+	Conditions []v1.Condition `json:"conditions,omitempty"`
+}
+
+// StatusConditions returns a pointer to s.Conditions so callers (meta.SetStatusCondition) can
+// update it in place.
+func (s *Status) StatusConditions() *[]v1.Condition {
+	return &s.Conditions
+}
+
+// SecretRef points at a key within a Kubernetes Secret in the same namespace as the CR
+// referencing it. It lets a sensitive spec field - an OIDC/GitHub client secret, a SAML
+// signing key pair - be sourced from a Secret instead of being inlined as plaintext in the CR.
+type SecretRef struct {
+	// Name is the Secret's name. The Secret must live in the same namespace as the CR that
+	// references it; cross-namespace references aren't supported so a connector CR can't be
+	// used to read a Secret its author wouldn't otherwise have access to.
+	Name string `json:"name"`
+	// Key is the key within the Secret's Data/StringData to read.
+	Key string `json:"key"`
+}
+
+// GroupResolverConfig describes an IdP endpoint that lists a user's group/team/org
+// memberships, in the style of the dex CloudFoundry connector's org and space lookup against
+// the CF API. It's shared by the OIDC and SAML connector specs. The operator validates it and
+// reports the result in the GroupResolverConfigured condition; it isn't passed to Teleport,
+// and no memberships are fetched with it at login.
+type GroupResolverConfig struct {
+	// APIURL is the IdP endpoint to call for group membership, e.g. a CF API org/space
+	// membership listing.
+	APIURL string `json:"apiURL"`
+	// TokenSource selects how the resolver authenticates to APIURL: "access_token" reuses the
+	// access token returned at login, "secretRef" uses TokenSourceRef instead.
+	TokenSource string `json:"tokenSource"`
+	// TokenSourceRef sources a service credential from a Secret, when TokenSource is
+	// "secretRef".
+	TokenSourceRef *SecretRef `json:"tokenSourceRef,omitempty"`
+	// CABundleRef sources a PEM CA bundle from a Secret, for IdPs using a private CA.
+	CABundleRef *SecretRef `json:"caBundleRef,omitempty"`
+	// Expression is a JSONPath/CEL expression producing the group strings from APIURL's
+	// response body.
+	Expression string `json:"expression"`
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2023 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v2 holds the v2 Teleport resource CRDs: the ones whose Teleport-side resource is
+// itself versioned V2 (SAMLConnectorV2, ...).
+package v2
+
+import (
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/operator/apis/resources"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// TeleportSAMLConnector is the Kubernetes CR representation of a Teleport SAML connector.
+type TeleportSAMLConnector struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TeleportSAMLConnectorSpec `json:"spec"`
+	Status resources.Status          `json:"status"`
+}
+
+// TeleportSAMLConnectorAttributeToRole mirrors types.AttributeMapping.
+type TeleportSAMLConnectorAttributeToRole struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+// TeleportSAMLConnectorSpec mirrors the JSON-facing fields of types.SAMLConnectorSpecV2.
+// EntityDescriptorRef and SigningKeyPairRef, when set, take precedence over their plaintext
+// counterparts: the reconciler resolves them against a Kubernetes Secret before every upsert,
+// so the signing/encryption key material and the IdP metadata never have to be inlined in the
+// CR. This is analogous to how external SSO connectors (e.g. the dex CloudFoundry connector)
+// accept their key material from out-of-band sources rather than the connector config blob.
+type TeleportSAMLConnectorSpec struct {
+	Issuer                   string                                 `json:"issuer"`
+	SSO                      string                                 `json:"sso"`
+	AssertionConsumerService string                                 `json:"acs"`
+	Audience                 string                                 `json:"audience,omitempty"`
+	ServiceProviderIssuer    string                                 `json:"service_provider_issuer,omitempty"`
+	AttributesToRoles        []TeleportSAMLConnectorAttributeToRole `json:"attributes_to_roles,omitempty"`
+	EntityDescriptor         string                                 `json:"entity_descriptor,omitempty"`
+	// EntityDescriptorRef sources EntityDescriptor (the IdP metadata XML) from a Secret.
+	EntityDescriptorRef *resources.SecretRef `json:"entityDescriptorRef,omitempty"`
+	SigningKeyPair      *TeleportSAMLConnectorKeyPair `json:"signing_key_pair,omitempty"`
+	// SigningKeyPairRef sources SigningKeyPair's PrivateKey from a Secret.
+	SigningKeyPairRef *resources.SecretRef `json:"signingKeyPairRef,omitempty"`
+	EncryptionKeyPair *TeleportSAMLConnectorKeyPair `json:"encryption_key_pair,omitempty"`
+	// EncryptionKeyPairRef sources EncryptionKeyPair's PrivateKey from a Secret.
+	EncryptionKeyPairRef *resources.SecretRef `json:"encryptionKeyPairRef,omitempty"`
+	// GroupResolver describes a group-membership lookup for attributes_to_roles input. The
+	// operator only validates it; it isn't sent to Teleport and doesn't change login.
+	GroupResolver *resources.GroupResolverConfig `json:"groupResolver,omitempty"`
+}
+
+// TeleportSAMLConnectorKeyPair mirrors types.AsymmetricKeyPair.
+type TeleportSAMLConnectorKeyPair struct {
+	PrivateKey string `json:"private_key,omitempty"`
+	Cert       string `json:"cert,omitempty"`
+}
+
+// ToTeleport converts the CR into the types.SAMLConnector Teleport's API expects. It never
+// resolves EntityDescriptorRef/SigningKeyPairRef/EncryptionKeyPairRef itself - that needs a
+// Kubernetes client and happens in the reconciler, via the SecretResolver hook, before
+// ToTeleport's result is upserted.
+func (c *TeleportSAMLConnector) ToTeleport() types.SAMLConnector {
+	attributesToRoles := make([]types.AttributeMapping, 0, len(c.Spec.AttributesToRoles))
+	for _, a := range c.Spec.AttributesToRoles {
+		attributesToRoles = append(attributesToRoles, types.AttributeMapping{
+			Name:  a.Name,
+			Value: a.Value,
+			Roles: a.Roles,
+		})
+	}
+
+	spec := types.SAMLConnectorSpecV2{
+		Issuer:                   c.Spec.Issuer,
+		SSO:                      c.Spec.SSO,
+		AssertionConsumerService: c.Spec.AssertionConsumerService,
+		Audience:                 c.Spec.Audience,
+		ServiceProviderIssuer:    c.Spec.ServiceProviderIssuer,
+		AttributesToRoles:        attributesToRoles,
+		EntityDescriptor:         c.Spec.EntityDescriptor,
+	}
+	if c.Spec.SigningKeyPair != nil {
+		spec.SigningKeyPair = &types.AsymmetricKeyPair{
+			PrivateKey: c.Spec.SigningKeyPair.PrivateKey,
+			Cert:       c.Spec.SigningKeyPair.Cert,
+		}
+	}
+	if c.Spec.EncryptionKeyPair != nil {
+		spec.EncryptionKeyPair = &types.AsymmetricKeyPair{
+			PrivateKey: c.Spec.EncryptionKeyPair.PrivateKey,
+			Cert:       c.Spec.EncryptionKeyPair.Cert,
+		}
+	}
+
+	return &types.SAMLConnectorV2{
+		ResourceHeader: types.ResourceHeader{
+			Metadata: types.Metadata{
+				Name:   c.Name,
+				Labels: c.Labels,
+			},
+		},
+		Spec: spec,
+	}
+}
+
+// StatusConditions implements TeleportKubernetesResource.
+func (c *TeleportSAMLConnector) StatusConditions() *[]metav1.Condition {
+	return c.Status.StatusConditions()
+}
+
+// ReferencedRoleNames returns every Teleport role name this connector's attributes_to_roles
+// mapping references, so the reconciler can validate they exist before upserting.
+func (c *TeleportSAMLConnector) ReferencedRoleNames() []string {
+	var names []string
+	for _, a := range c.Spec.AttributesToRoles {
+		names = append(names, a.Roles...)
+	}
+	return names
+}
+
+// ReferencesSecret reports whether any of this connector's secretRef fields, including its
+// GroupResolver's TokenSourceRef/CABundleRef, point at the named Secret, so a Secret watch
+// can find the CRs a change to it should requeue.
+func (c *TeleportSAMLConnector) ReferencesSecret(name string) bool {
+	if c.Spec.EntityDescriptorRef != nil && c.Spec.EntityDescriptorRef.Name == name ||
+		c.Spec.SigningKeyPairRef != nil && c.Spec.SigningKeyPairRef.Name == name ||
+		c.Spec.EncryptionKeyPairRef != nil && c.Spec.EncryptionKeyPairRef.Name == name {
+		return true
+	}
+	if c.Spec.GroupResolver == nil {
+		return false
+	}
+	return c.Spec.GroupResolver.TokenSourceRef != nil && c.Spec.GroupResolver.TokenSourceRef.Name == name ||
+		c.Spec.GroupResolver.CABundleRef != nil && c.Spec.GroupResolver.CABundleRef.Name == name
+}
+
+// DeepCopyObject implements runtime.Object.
+func (c *TeleportSAMLConnector) DeepCopyObject() runtime.Object {
+	return c.DeepCopy()
+}
+
+// DeepCopy returns a deep copy of c.
+func (c *TeleportSAMLConnector) DeepCopy() *TeleportSAMLConnector {
+	if c == nil {
+		return nil
+	}
+	out := new(TeleportSAMLConnector)
+	out.TypeMeta = c.TypeMeta
+	c.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = c.Spec
+	if c.Spec.AttributesToRoles != nil {
+		out.Spec.AttributesToRoles = append([]TeleportSAMLConnectorAttributeToRole(nil), c.Spec.AttributesToRoles...)
+	}
+	if c.Spec.SigningKeyPair != nil {
+		kp := *c.Spec.SigningKeyPair
+		out.Spec.SigningKeyPair = &kp
+	}
+	if c.Spec.EncryptionKeyPair != nil {
+		kp := *c.Spec.EncryptionKeyPair
+		out.Spec.EncryptionKeyPair = &kp
+	}
+	if c.Spec.EntityDescriptorRef != nil {
+		ref := *c.Spec.EntityDescriptorRef
+		out.Spec.EntityDescriptorRef = &ref
+	}
+	if c.Spec.SigningKeyPairRef != nil {
+		ref := *c.Spec.SigningKeyPairRef
+		out.Spec.SigningKeyPairRef = &ref
+	}
+	if c.Spec.EncryptionKeyPairRef != nil {
+		ref := *c.Spec.EncryptionKeyPairRef
+		out.Spec.EncryptionKeyPairRef = &ref
+	}
+	if c.Spec.GroupResolver != nil {
+		resolver := *c.Spec.GroupResolver
+		if c.Spec.GroupResolver.TokenSourceRef != nil {
+			ref := *c.Spec.GroupResolver.TokenSourceRef
+			resolver.TokenSourceRef = &ref
+		}
+		if c.Spec.GroupResolver.CABundleRef != nil {
+			ref := *c.Spec.GroupResolver.CABundleRef
+			resolver.CABundleRef = &ref
+		}
+		out.Spec.GroupResolver = &resolver
+	}
+	if c.Status.Conditions != nil {
+		out.Status.Conditions = append([]metav1.Condition(nil), c.Status.Conditions...)
+	}
+	return out
+}
+
+// DeepCopyInto copies c into out.
+func (c *TeleportSAMLConnector) DeepCopyInto(out *TeleportSAMLConnector) {
+	*out = *c.DeepCopy()
+}
+
+// +kubebuilder:object:root=true
+
+// TeleportSAMLConnectorList contains a list of TeleportSAMLConnector.
+type TeleportSAMLConnectorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TeleportSAMLConnector `json:"items"`
+}
+
+// DeepCopyObject implements runtime.Object.
+func (l *TeleportSAMLConnectorList) DeepCopyObject() runtime.Object {
+	out := new(TeleportSAMLConnectorList)
+	out.TypeMeta = l.TypeMeta
+	l.ListMeta.DeepCopyInto(&out.ListMeta)
+	if l.Items != nil {
+		out.Items = make([]TeleportSAMLConnector, len(l.Items))
+		for i := range l.Items {
+			l.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+	return out
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2023 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v3
+
+import (
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/operator/apis/resources"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// TeleportGithubConnector is the Kubernetes CR representation of a Teleport GitHub connector.
+type TeleportGithubConnector struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TeleportGithubConnectorSpec `json:"spec"`
+	Status resources.Status            `json:"status"`
+}
+
+// TeleportGithubConnectorTeamsToLogins mirrors types.TeamMapping.
+type TeleportGithubConnectorTeamsToLogins struct {
+	Organization string   `json:"organization"`
+	Team         string   `json:"team"`
+	Logins       []string `json:"logins,omitempty"`
+	KubeUsers    []string `json:"kubeUsers,omitempty"`
+	KubeGroups   []string `json:"kubeGroups,omitempty"`
+}
+
+// TeleportGithubConnectorTeamsToRoles mirrors types.TeamRolesMapping.
+type TeleportGithubConnectorTeamsToRoles struct {
+	Organization string   `json:"organization"`
+	Team         string   `json:"team"`
+	Roles        []string `json:"roles,omitempty"`
+}
+
+// TeleportGithubConnectorSpec mirrors the JSON-facing fields of types.GithubConnectorSpecV3.
+// ClientSecretRef, when set, takes precedence over ClientSecret: the reconciler resolves it
+// against a Kubernetes Secret before every upsert, so the secret itself never has to be
+// inlined in the CR.
+type TeleportGithubConnectorSpec struct {
+	ClientID      string                                 `json:"client_id"`
+	ClientSecret  string                                 `json:"client_secret"`
+	RedirectURL   string                                 `json:"redirect_url"`
+	Display       string                                 `json:"display,omitempty"`
+	TeamsToLogins []TeleportGithubConnectorTeamsToLogins `json:"teams_to_logins,omitempty"`
+	TeamsToRoles  []TeleportGithubConnectorTeamsToRoles  `json:"teams_to_roles,omitempty"`
+	// ClientSecretRef sources ClientSecret from a Secret instead of this CR's plaintext spec.
+	ClientSecretRef *resources.SecretRef `json:"clientSecretRef,omitempty"`
+}
+
+// ToTeleport converts the CR into the types.GithubConnector Teleport's API expects. It never
+// sets ClientSecret from SecretRef - that resolution needs a Kubernetes client and happens in
+// the reconciler, via the SecretResolver hook, before ToTeleport's result is upserted.
+func (c *TeleportGithubConnector) ToTeleport() types.GithubConnector {
+	teamsToLogins := make([]types.TeamMapping, 0, len(c.Spec.TeamsToLogins))
+	for _, t := range c.Spec.TeamsToLogins {
+		teamsToLogins = append(teamsToLogins, types.TeamMapping{
+			Organization: t.Organization,
+			Team:         t.Team,
+			Logins:       t.Logins,
+			KubeUsers:    t.KubeUsers,
+			KubeGroups:   t.KubeGroups,
+		})
+	}
+
+	teamsToRoles := make([]types.TeamRolesMapping, 0, len(c.Spec.TeamsToRoles))
+	for _, t := range c.Spec.TeamsToRoles {
+		teamsToRoles = append(teamsToRoles, types.TeamRolesMapping{
+			Organization: t.Organization,
+			Team:         t.Team,
+			Roles:        t.Roles,
+		})
+	}
+
+	return &types.GithubConnectorV3{
+		ResourceHeader: types.ResourceHeader{
+			Metadata: types.Metadata{
+				Name:   c.Name,
+				Labels: c.Labels,
+			},
+		},
+		Spec: types.GithubConnectorSpecV3{
+			ClientID:      c.Spec.ClientID,
+			ClientSecret:  c.Spec.ClientSecret,
+			RedirectURL:   c.Spec.RedirectURL,
+			Display:       c.Spec.Display,
+			TeamsToLogins: teamsToLogins,
+			TeamsToRoles:  teamsToRoles,
+		},
+	}
+}
+
+// ReferencedRoleNames returns every Teleport role name this connector's teams_to_roles
+// mapping references, so the reconciler can validate they exist before upserting.
+func (c *TeleportGithubConnector) ReferencedRoleNames() []string {
+	var names []string
+	for _, t := range c.Spec.TeamsToRoles {
+		names = append(names, t.Roles...)
+	}
+	return names
+}
+
+// StatusConditions implements TeleportKubernetesResource.
+func (c *TeleportGithubConnector) StatusConditions() *[]metav1.Condition {
+	return c.Status.StatusConditions()
+}
+
+// ReferencesSecret reports whether this connector's ClientSecretRef points at the named
+// Secret, so a Secret watch can find the CRs a change to it should requeue.
+func (c *TeleportGithubConnector) ReferencesSecret(name string) bool {
+	return c.Spec.ClientSecretRef != nil && c.Spec.ClientSecretRef.Name == name
+}
+
+// DeepCopyObject implements runtime.Object.
+func (c *TeleportGithubConnector) DeepCopyObject() runtime.Object {
+	return c.DeepCopy()
+}
+
+// DeepCopy returns a deep copy of c.
+func (c *TeleportGithubConnector) DeepCopy() *TeleportGithubConnector {
+	if c == nil {
+		return nil
+	}
+	out := new(TeleportGithubConnector)
+	out.TypeMeta = c.TypeMeta
+	c.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = c.Spec
+	if c.Spec.TeamsToLogins != nil {
+		out.Spec.TeamsToLogins = append([]TeleportGithubConnectorTeamsToLogins(nil), c.Spec.TeamsToLogins...)
+	}
+	if c.Spec.TeamsToRoles != nil {
+		out.Spec.TeamsToRoles = append([]TeleportGithubConnectorTeamsToRoles(nil), c.Spec.TeamsToRoles...)
+	}
+	if c.Spec.ClientSecretRef != nil {
+		ref := *c.Spec.ClientSecretRef
+		out.Spec.ClientSecretRef = &ref
+	}
+	if c.Status.Conditions != nil {
+		out.Status.Conditions = append([]metav1.Condition(nil), c.Status.Conditions...)
+	}
+	return out
+}
+
+// DeepCopyInto copies c into out.
+func (c *TeleportGithubConnector) DeepCopyInto(out *TeleportGithubConnector) {
+	*out = *c.DeepCopy()
+}
+
+// +kubebuilder:object:root=true
+
+// TeleportGithubConnectorList contains a list of TeleportGithubConnector.
+type TeleportGithubConnectorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TeleportGithubConnector `json:"items"`
+}
+
+// DeepCopyObject implements runtime.Object.
+func (l *TeleportGithubConnectorList) DeepCopyObject() runtime.Object {
+	out := new(TeleportGithubConnectorList)
+	out.TypeMeta = l.TypeMeta
+	l.ListMeta.DeepCopyInto(&out.ListMeta)
+	if l.Items != nil {
+		out.Items = make([]TeleportGithubConnector, len(l.Items))
+		for i := range l.Items {
+			l.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+	return out
+}
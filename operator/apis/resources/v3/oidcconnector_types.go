@@ -0,0 +1,209 @@
+/*
+Copyright 2023 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v3 holds the v3 Teleport resource CRDs: the ones whose Teleport-side resource is
+// itself versioned V3 (OIDCConnectorV3, GithubConnectorV3, ...).
+package v3
+
+import (
+	"encoding/json"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/api/types/wrappers"
+	"github.com/gravitational/teleport/operator/apis/resources"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// TeleportOIDCConnector is the Kubernetes CR representation of a Teleport OIDC connector.
+type TeleportOIDCConnector struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TeleportOIDCConnectorSpec `json:"spec"`
+	Status resources.Status          `json:"status"`
+}
+
+// TeleportOIDCConnectorClaimToRole mirrors types.ClaimMapping.
+type TeleportOIDCConnectorClaimToRole struct {
+	Claim string   `json:"claim"`
+	Value string   `json:"value"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+// TeleportOIDCConnectorSpec mirrors the JSON-facing fields of types.OIDCConnectorSpecV3.
+// ClientSecretRef, when set, takes precedence over ClientSecret: the reconciler resolves it
+// against a Kubernetes Secret before every upsert, so the secret itself never has to be
+// inlined in the CR.
+type TeleportOIDCConnectorSpec struct {
+	RedirectURLs  wrappers.Strings                   `json:"redirect_url"`
+	IssuerURL     string                             `json:"issuer_url"`
+	ClientID      string                             `json:"client_id"`
+	ClientSecret  string                             `json:"client_secret"`
+	ClaimsToRoles []TeleportOIDCConnectorClaimToRole `json:"claims_to_roles,omitempty"`
+	// ClientSecretRef sources ClientSecret from a Secret instead of this CR's plaintext spec.
+	ClientSecretRef *resources.SecretRef `json:"clientSecretRef,omitempty"`
+	// GroupResolver describes a group-membership lookup for claims_to_roles input. The
+	// operator only validates it; it isn't sent to Teleport and doesn't change login.
+	GroupResolver *resources.GroupResolverConfig `json:"groupResolver,omitempty"`
+}
+
+// MarshalJSON always serializes RedirectURLs as a JSON array, even for a single element,
+// unlike wrappers.Strings' own collapsing behavior - the CRD's OpenAPI schema declares
+// redirect_url as an array, so a collapsed bare string would fail validation.
+func (s TeleportOIDCConnectorSpec) MarshalJSON() ([]byte, error) {
+	type alias TeleportOIDCConnectorSpec
+	redirectURLs := []string(s.RedirectURLs)
+	if redirectURLs == nil {
+		redirectURLs = []string{}
+	}
+	return json.Marshal(struct {
+		RedirectURLs []string `json:"redirect_url"`
+		alias
+	}{
+		RedirectURLs: redirectURLs,
+		alias:        alias(s),
+	})
+}
+
+// ToTeleport converts the CR into the types.OIDCConnector Teleport's API expects. It never
+// sets ClientSecret from SecretRef - that resolution needs a Kubernetes client and happens in
+// the reconciler, via the SecretResolver hook, before ToTeleport's result is upserted.
+func (c *TeleportOIDCConnector) ToTeleport() types.OIDCConnector {
+	claimsToRoles := make([]types.ClaimMapping, 0, len(c.Spec.ClaimsToRoles))
+	for _, m := range c.Spec.ClaimsToRoles {
+		claimsToRoles = append(claimsToRoles, types.ClaimMapping{
+			Claim: m.Claim,
+			Value: m.Value,
+			Roles: m.Roles,
+		})
+	}
+
+	return &types.OIDCConnectorV3{
+		ResourceHeader: types.ResourceHeader{
+			Metadata: types.Metadata{
+				Name:   c.Name,
+				Labels: c.Labels,
+			},
+		},
+		Spec: types.OIDCConnectorSpecV3{
+			IssuerURL:     c.Spec.IssuerURL,
+			ClientID:      c.Spec.ClientID,
+			ClientSecret:  c.Spec.ClientSecret,
+			RedirectURLs:  c.Spec.RedirectURLs,
+			ClaimsToRoles: claimsToRoles,
+		},
+	}
+}
+
+// ReferencedRoleNames returns every Teleport role name this connector's claims_to_roles
+// mapping references, so the reconciler can validate they exist before upserting.
+func (c *TeleportOIDCConnector) ReferencedRoleNames() []string {
+	var names []string
+	for _, m := range c.Spec.ClaimsToRoles {
+		names = append(names, m.Roles...)
+	}
+	return names
+}
+
+// StatusConditions implements TeleportKubernetesResource.
+func (c *TeleportOIDCConnector) StatusConditions() *[]metav1.Condition {
+	return c.Status.StatusConditions()
+}
+
+// ReferencesSecret reports whether this connector's ClientSecretRef, or its GroupResolver's
+// TokenSourceRef/CABundleRef, points at the named Secret, so a Secret watch can find the CRs
+// a change to it should requeue.
+func (c *TeleportOIDCConnector) ReferencesSecret(name string) bool {
+	if c.Spec.ClientSecretRef != nil && c.Spec.ClientSecretRef.Name == name {
+		return true
+	}
+	if c.Spec.GroupResolver == nil {
+		return false
+	}
+	return c.Spec.GroupResolver.TokenSourceRef != nil && c.Spec.GroupResolver.TokenSourceRef.Name == name ||
+		c.Spec.GroupResolver.CABundleRef != nil && c.Spec.GroupResolver.CABundleRef.Name == name
+}
+
+// DeepCopyObject implements runtime.Object.
+func (c *TeleportOIDCConnector) DeepCopyObject() runtime.Object {
+	return c.DeepCopy()
+}
+
+// DeepCopy returns a deep copy of c.
+func (c *TeleportOIDCConnector) DeepCopy() *TeleportOIDCConnector {
+	if c == nil {
+		return nil
+	}
+	out := new(TeleportOIDCConnector)
+	out.TypeMeta = c.TypeMeta
+	c.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = c.Spec
+	if c.Spec.RedirectURLs != nil {
+		out.Spec.RedirectURLs = append(wrappers.Strings(nil), c.Spec.RedirectURLs...)
+	}
+	if c.Spec.ClaimsToRoles != nil {
+		out.Spec.ClaimsToRoles = append([]TeleportOIDCConnectorClaimToRole(nil), c.Spec.ClaimsToRoles...)
+	}
+	if c.Spec.ClientSecretRef != nil {
+		ref := *c.Spec.ClientSecretRef
+		out.Spec.ClientSecretRef = &ref
+	}
+	if c.Spec.GroupResolver != nil {
+		resolver := *c.Spec.GroupResolver
+		if c.Spec.GroupResolver.TokenSourceRef != nil {
+			ref := *c.Spec.GroupResolver.TokenSourceRef
+			resolver.TokenSourceRef = &ref
+		}
+		if c.Spec.GroupResolver.CABundleRef != nil {
+			ref := *c.Spec.GroupResolver.CABundleRef
+			resolver.CABundleRef = &ref
+		}
+		out.Spec.GroupResolver = &resolver
+	}
+	if c.Status.Conditions != nil {
+		out.Status.Conditions = append([]metav1.Condition(nil), c.Status.Conditions...)
+	}
+	return out
+}
+
+// +kubebuilder:object:root=true
+
+// TeleportOIDCConnectorList contains a list of TeleportOIDCConnector.
+type TeleportOIDCConnectorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TeleportOIDCConnector `json:"items"`
+}
+
+// DeepCopyObject implements runtime.Object.
+func (l *TeleportOIDCConnectorList) DeepCopyObject() runtime.Object {
+	out := new(TeleportOIDCConnectorList)
+	out.TypeMeta = l.TypeMeta
+	l.ListMeta.DeepCopyInto(&out.ListMeta)
+	if l.Items != nil {
+		out.Items = make([]TeleportOIDCConnector, len(l.Items))
+		for i := range l.Items {
+			l.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+	return out
+}
+
+// DeepCopyInto copies c into out.
+func (c *TeleportOIDCConnector) DeepCopyInto(out *TeleportOIDCConnector) {
+	*out = *c.DeepCopy()
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v6 holds the v6 Teleport resource CRDs: the ones whose Teleport-side resource is
+// itself versioned V6 (RoleV6, ...).
+package v6
+
+import (
+	"github.com/gravitational/teleport/operator/apis/resources"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// TeleportRole is the Kubernetes CR representation of a Teleport role. It doesn't have its
+// own reconciler yet, so Spec is intentionally left unstructured: today, the only thing that
+// consumes a TeleportRole is the connector reconcilers' claims_to_roles/teams_to_roles/
+// attributes_to_roles validation, which only needs a role CR's existence (by name), not its
+// contents.
+type TeleportRole struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TeleportRoleSpec `json:"spec"`
+	Status resources.Status `json:"status"`
+}
+
+// TeleportRoleSpec is a placeholder for the fields of types.RoleSpecV6, kept unstructured
+// until TeleportRole gets its own reconciler.
+type TeleportRoleSpec struct {
+	Allow map[string]interface{} `json:"allow,omitempty"`
+	Deny  map[string]interface{} `json:"deny,omitempty"`
+}
+
+// StatusConditions implements TeleportKubernetesResource.
+func (r *TeleportRole) StatusConditions() *[]metav1.Condition {
+	return r.Status.StatusConditions()
+}
+
+// DeepCopyObject implements runtime.Object.
+func (r *TeleportRole) DeepCopyObject() runtime.Object {
+	return r.DeepCopy()
+}
+
+// DeepCopy returns a deep copy of r.
+func (r *TeleportRole) DeepCopy() *TeleportRole {
+	if r == nil {
+		return nil
+	}
+	out := new(TeleportRole)
+	out.TypeMeta = r.TypeMeta
+	r.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = r.Spec
+	if r.Spec.Allow != nil {
+		out.Spec.Allow = make(map[string]interface{}, len(r.Spec.Allow))
+		for k, v := range r.Spec.Allow {
+			out.Spec.Allow[k] = v
+		}
+	}
+	if r.Spec.Deny != nil {
+		out.Spec.Deny = make(map[string]interface{}, len(r.Spec.Deny))
+		for k, v := range r.Spec.Deny {
+			out.Spec.Deny[k] = v
+		}
+	}
+	if r.Status.Conditions != nil {
+		out.Status.Conditions = append([]metav1.Condition(nil), r.Status.Conditions...)
+	}
+	return out
+}
+
+// DeepCopyInto copies r into out.
+func (r *TeleportRole) DeepCopyInto(out *TeleportRole) {
+	*out = *r.DeepCopy()
+}
+
+// +kubebuilder:object:root=true
+
+// TeleportRoleList contains a list of TeleportRole.
+type TeleportRoleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TeleportRole `json:"items"`
+}
+
+// DeepCopyObject implements runtime.Object.
+func (l *TeleportRoleList) DeepCopyObject() runtime.Object {
+	out := new(TeleportRoleList)
+	out.TypeMeta = l.TypeMeta
+	l.ListMeta.DeepCopyInto(&out.ListMeta)
+	if l.Items != nil {
+		out.Items = make([]TeleportRole, len(l.Items))
+		for i := range l.Items {
+			l.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+	return out
+}
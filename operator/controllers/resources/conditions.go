@@ -0,0 +1,238 @@
+/*
+Copyright 2023 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/trace"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConditionTypeOwnershipValidated reports whether the Kubernetes CR is allowed to manage
+// the Teleport resource of the same name: true if the Teleport resource doesn't exist yet or
+// was itself created by the operator, false if it already exists with a different origin (in
+// which case the operator must not clobber it).
+const ConditionTypeOwnershipValidated = "OwnershipValidated"
+
+// ConditionTypeTeleportSynced reports the outcome of creating/updating the Teleport-side
+// resource: true once it matches the Kubernetes spec, unknown if that step was skipped
+// because secret resolution, role validation, groupResolver validation or ownership
+// validation failed first.
+const ConditionTypeTeleportSynced = "TeleportSynced"
+
+// ConditionTypeStatusPersisted reports whether the operator managed to write this CR's
+// status subresource back to the Kubernetes API server.
+const ConditionTypeStatusPersisted = "StatusPersisted"
+
+// ConditionTypeSecretsResolved reports whether every secretRef field on the CR (e.g. an OIDC
+// client secret sourced from a Secret) was successfully resolved before this sync.
+const ConditionTypeSecretsResolved = "SecretsResolved"
+
+const (
+	reasonSecretsResolved       = "SecretsResolved"
+	reasonSecretsResolutionFailed = "SecretResolutionFailed"
+)
+
+// getSecretsResolvedCondition reports the outcome of resolving a CR's secretRef fields.
+func getSecretsResolvedCondition(err error) v1.Condition {
+	if err == nil {
+		return v1.Condition{
+			Type:    ConditionTypeSecretsResolved,
+			Status:  v1.ConditionTrue,
+			Reason:  reasonSecretsResolved,
+			Message: "All secretRef fields were resolved",
+		}
+	}
+
+	return v1.Condition{
+		Type:    ConditionTypeSecretsResolved,
+		Status:  v1.ConditionFalse,
+		Reason:  reasonSecretsResolutionFailed,
+		Message: err.Error(),
+	}
+}
+
+// ConditionTypeRolesResolved reports whether every Teleport role name this CR's
+// claims_to_roles/teams_to_roles/attributes_to_roles mapping references exists, either on the
+// Teleport server or as a TeleportRole CR.
+const ConditionTypeRolesResolved = "RolesResolved"
+
+const (
+	reasonRolesResolved = "RolesResolved"
+	reasonRolesMissing  = "RolesNotFound"
+	reasonRolesLookupFailed = "RoleLookupFailed"
+)
+
+// getRolesResolvedCondition reports the outcome of validating a CR's referenced role names.
+// err, when set, means the lookup itself failed (e.g. the Teleport auth server was
+// unreachable) rather than that a role was missing, and takes precedence in the reported
+// condition; missing lists the role names that don't exist anywhere.
+func getRolesResolvedCondition(missing []string, err error) v1.Condition {
+	if err != nil {
+		return v1.Condition{
+			Type:    ConditionTypeRolesResolved,
+			Status:  v1.ConditionFalse,
+			Reason:  reasonRolesLookupFailed,
+			Message: err.Error(),
+		}
+	}
+
+	if len(missing) > 0 {
+		return v1.Condition{
+			Type:    ConditionTypeRolesResolved,
+			Status:  v1.ConditionFalse,
+			Reason:  reasonRolesMissing,
+			Message: fmt.Sprintf("Referenced roles not found: %s", strings.Join(missing, ", ")),
+		}
+	}
+
+	return v1.Condition{
+		Type:    ConditionTypeRolesResolved,
+		Status:  v1.ConditionTrue,
+		Reason:  reasonRolesResolved,
+		Message: "All referenced roles exist",
+	}
+}
+
+// ConditionTypeGroupResolverConfigured reports whether a CR's groupResolver block, if any, is
+// well-formed: its URL, token source, CA bundle, and expression all check out.
+const ConditionTypeGroupResolverConfigured = "GroupResolverConfigured"
+
+const (
+	reasonGroupResolverConfigured = "GroupResolverConfigured"
+	reasonGroupResolverInvalid    = "GroupResolverInvalid"
+)
+
+// getGroupResolverConfiguredCondition reports the outcome of validating a CR's groupResolver
+// block. A CR with no groupResolver block at all reports true too - that's a valid state, not
+// an error.
+func getGroupResolverConfiguredCondition(err error) v1.Condition {
+	if err != nil {
+		return v1.Condition{
+			Type:    ConditionTypeGroupResolverConfigured,
+			Status:  v1.ConditionFalse,
+			Reason:  reasonGroupResolverInvalid,
+			Message: err.Error(),
+		}
+	}
+
+	return v1.Condition{
+		Type:    ConditionTypeGroupResolverConfigured,
+		Status:  v1.ConditionTrue,
+		Reason:  reasonGroupResolverConfigured,
+		Message: "No groupResolver configured, or its configuration is valid",
+	}
+}
+
+const (
+	reasonOriginKubernetes    = "OriginKubernetes"
+	reasonOriginNotKubernetes = "OriginNotKubernetes"
+)
+
+// checkOwnership reports whether the operator owns existingResource, returning the
+// OwnershipValidated condition to record either way. existingResource is the current state
+// of the Teleport-side resource when it already exists; ownership is granted when that
+// resource doesn't exist yet, or was itself created through the Kubernetes origin. An error is
+// returned when a pre-existing, non-Kubernetes-origin resource would otherwise be overwritten.
+func checkOwnership[T types.ResourceWithOrigin](existingResource T, exists bool) (v1.Condition, error) {
+	if !exists || existingResource.Origin() == types.OriginKubernetes {
+		return v1.Condition{
+			Type:    ConditionTypeOwnershipValidated,
+			Status:  v1.ConditionTrue,
+			Reason:  reasonOriginKubernetes,
+			Message: "Teleport resource is managed by this operator",
+		}, nil
+	}
+
+	return v1.Condition{
+			Type:    ConditionTypeOwnershipValidated,
+			Status:  v1.ConditionFalse,
+			Reason:  reasonOriginNotKubernetes,
+			Message: "A Teleport resource with the same name already exists with a different origin, refusing to overwrite it",
+		}, trace.AlreadyExists("Teleport resource %q already exists and is not managed by Kubernetes",
+			existingResource.GetName())
+}
+
+// silentUpdateStatus persists obj's status subresource, logging nothing and swallowing the
+// error: it's called from paths that are already about to return a more meaningful error of
+// their own, and we don't want a secondary status-write failure to mask it.
+func silentUpdateStatus(ctx context.Context, c kclient.Client, obj kclient.Object) {
+	_ = c.Status().Update(ctx, obj)
+}
+
+// ConditionTypeDriftDetected reports whether the Teleport resource was changed or recreated
+// out-of-band since the operator last synced it. Its LastTransitionTime doubles as the
+// LastObservedDrift timestamp: it only moves when the condition's Status flips.
+const ConditionTypeDriftDetected = "DriftDetected"
+
+const (
+	reasonNoDrift         = "InSync"
+	reasonOutOfBandChange = "ChangedOutOfBand"
+)
+
+// revisionTracker remembers, per resource name, the Teleport revision the operator last
+// wrote, so a later sync can tell its own update apart from one made directly against
+// Teleport. It's process-local and empties on restart, same tradeoff as the reconciler's
+// transientFailureBackoff.
+type revisionTracker struct {
+	mu        sync.Mutex
+	revisions map[string]string
+}
+
+func newRevisionTracker() revisionTracker {
+	return revisionTracker{revisions: make(map[string]string)}
+}
+
+// check compares observedRevision against the last revision recorded for name and returns
+// the DriftDetected condition to set: false if this is the first time we've seen the
+// resource or the revision hasn't changed, true only when we previously recorded a revision
+// and it differs.
+func (t *revisionTracker) check(name, observedRevision string) v1.Condition {
+	t.mu.Lock()
+	last, known := t.revisions[name]
+	t.mu.Unlock()
+
+	if known && last != observedRevision {
+		return v1.Condition{
+			Type:    ConditionTypeDriftDetected,
+			Status:  v1.ConditionTrue,
+			Reason:  reasonOutOfBandChange,
+			Message: "Teleport resource was modified or recreated outside of Kubernetes since the operator last synced it",
+		}
+	}
+	return v1.Condition{
+		Type:    ConditionTypeDriftDetected,
+		Status:  v1.ConditionFalse,
+		Reason:  reasonNoDrift,
+		Message: "Teleport resource matches the last version applied by the operator",
+	}
+}
+
+func (t *revisionTracker) record(name, revision string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.revisions[name] = revision
+}
+
+func (t *revisionTracker) forget(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.revisions, name)
+}
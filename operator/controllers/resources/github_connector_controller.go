@@ -2,68 +2,119 @@ package resources
 
 import (
 	"context"
+
 	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/auth"
 	resourcesv3 "github.com/gravitational/teleport/operator/apis/resources/v3"
+	resourcesv6 "github.com/gravitational/teleport/operator/apis/resources/v6"
 	"github.com/gravitational/teleport/operator/sidecar"
 	"github.com/gravitational/trace"
+	corev1 "k8s.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 type GithubConnectorReconciler struct {
 	*TeleportResourceReconciler[types.GithubConnector, *resourcesv3.TeleportGithubConnector]
-	TeleportClientAccessor sidecar.ClientAccessor
 }
 
 func NewGithubConnectorReconciler(client kclient.Client, accessor sidecar.ClientAccessor) *GithubConnectorReconciler {
-	oidcReconciler := &GithubConnectorReconciler{
-		TeleportResourceReconciler: nil,
-		TeleportClientAccessor:     accessor,
+	ops := TeleportClientOps[types.GithubConnector]{
+		Get: func(ctx context.Context, teleportClient auth.ClientI, name string) (types.GithubConnector, error) {
+			return teleportClient.GetGithubConnector(ctx, name, false /* with secrets*/)
+		},
+		Upsert: func(ctx context.Context, teleportClient auth.ClientI, oidc types.GithubConnector) error {
+			return teleportClient.UpsertGithubConnector(ctx, oidc)
+		},
+		Delete: func(ctx context.Context, teleportClient auth.ClientI, name string) error {
+			return teleportClient.DeleteGithubConnector(ctx, name)
+		},
 	}
 
-	resourceReconciler := NewTeleportResourceReconciler[types.GithubConnector, *resourcesv3.TeleportGithubConnector](
-		client,
-		oidcReconciler.GetGithubConnector,
-		oidcReconciler.UpsertGithubConnector,
-		oidcReconciler.UpsertGithubConnector,
-		oidcReconciler.DeleteGithubConnector)
-
-	oidcReconciler.TeleportResourceReconciler = resourceReconciler
-
-	return oidcReconciler
+	return &GithubConnectorReconciler{
+		TeleportResourceReconciler: NewTeleportClientReconciler[types.GithubConnector, *resourcesv3.TeleportGithubConnector](
+			client, accessor, ops,
+			WithMutator[types.GithubConnector, *resourcesv3.TeleportGithubConnector](githubConnectorMutator),
+			WithSecretResolver[types.GithubConnector, *resourcesv3.TeleportGithubConnector](githubSecretResolver),
+			WithRoleNamesExtractor[types.GithubConnector, *resourcesv3.TeleportGithubConnector](githubRoleNames),
+			WithSyncPeriod[types.GithubConnector, *resourcesv3.TeleportGithubConnector](DefaultSyncPeriod)),
+	}
 }
 
-func (r GithubConnectorReconciler) GetGithubConnector(ctx context.Context, name string) (types.GithubConnector, error) {
-	teleportClient, err := r.TeleportClientAccessor(ctx)
-	if err != nil {
-		return nil, trace.Wrap(err)
+// githubRoleNames extracts the role names connector's teams_to_roles mapping references.
+func githubRoleNames(k8sResource kclient.Object) []string {
+	connector, ok := k8sResource.(*resourcesv3.TeleportGithubConnector)
+	if !ok {
+		return nil
 	}
-
-	return teleportClient.GetGithubConnector(ctx, name, false /* with secrets*/)
+	return connector.ReferencedRoleNames()
 }
 
-func (r GithubConnectorReconciler) UpsertGithubConnector(ctx context.Context, oidc types.GithubConnector) error {
-	teleportClient, err := r.TeleportClientAccessor(ctx)
-	if err != nil {
-		return trace.Wrap(err)
+// githubConnectorMutator preserves the existing Teleport-side client secret when the CR
+// leaves client_secret unset, mirroring oidcConnectorMutator.
+func githubConnectorMutator(existing, desired types.GithubConnector, exists bool) types.GithubConnector {
+	if exists && desired.GetClientSecret() == "" {
+		desired.SetClientSecret(existing.GetClientSecret())
 	}
-
-	return teleportClient.UpsertGithubConnector(ctx, oidc)
+	return desired
 }
 
-func (r GithubConnectorReconciler) DeleteGithubConnector(ctx context.Context, name string) error {
-	teleportClient, err := r.TeleportClientAccessor(ctx)
-	if err != nil {
-		return trace.Wrap(err)
+// githubSecretResolver hydrates desired's client secret from the CR's clientSecretRef, when
+// set, mirroring oidcSecretResolver.
+func githubSecretResolver(ctx context.Context, c kclient.Client, k8sResource kclient.Object, desired types.GithubConnector) (types.GithubConnector, error) {
+	connector, ok := k8sResource.(*resourcesv3.TeleportGithubConnector)
+	if !ok || connector.Spec.ClientSecretRef == nil {
+		return desired, nil
 	}
 
-	return teleportClient.DeleteGithubConnector(ctx, name)
+	secret, err := resolveSecretRef(ctx, c, connector.Namespace, connector.Spec.ClientSecretRef)
+	if err != nil {
+		return desired, trace.Wrap(err)
+	}
+	desired.SetClientSecret(secret)
+	return desired, nil
 }
 
 func (r GithubConnectorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	return r.Do(ctx, req, &resourcesv3.TeleportGithubConnector{})
 }
 
+// +kubebuilder:rbac:groups=resources.teleport.dev,resources=teleportgithubconnectors,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=resources.teleport.dev,resources=teleportgithubconnectors/status,verbs=get;update;patch
+
 func (r GithubConnectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).For(&resourcesv3.TeleportGithubConnector{}).Complete(r)
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&resourcesv3.TeleportGithubConnector{}).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(watchReferencingSecrets(
+				r.Client,
+				func() *resourcesv3.TeleportGithubConnectorList { return &resourcesv3.TeleportGithubConnectorList{} },
+				func(list *resourcesv3.TeleportGithubConnectorList) []secretReferencingObject {
+					items := make([]secretReferencingObject, len(list.Items))
+					for i := range list.Items {
+						items[i] = &list.Items[i]
+					}
+					return items
+				},
+			)),
+		).
+		Watches(
+			&resourcesv6.TeleportRole{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj kclient.Object) []reconcile.Request {
+				role, ok := obj.(*resourcesv6.TeleportRole)
+				if !ok {
+					return nil
+				}
+				keys := r.roleIndex.dependentsOf(role.GetName())
+				requests := make([]reconcile.Request, len(keys))
+				for i, key := range keys {
+					requests[i] = reconcile.Request{NamespacedName: key}
+				}
+				return requests
+			}),
+		).
+		Complete(r)
 }
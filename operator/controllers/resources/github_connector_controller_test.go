@@ -55,6 +55,15 @@ func (g *githubTestingPrimitives) deleteTeleportResource(ctx context.Context, na
 	return trace.Wrap(g.setup.tClient.DeleteGithubConnector(ctx, name))
 }
 
+func (g *githubTestingPrimitives) modifyTeleportResource(ctx context.Context, name string) error {
+	github, err := g.setup.tClient.GetGithubConnector(ctx, name, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	github.SetClientID("modified out-of-band")
+	return trace.Wrap(g.setup.tClient.UpsertGithubConnector(ctx, github))
+}
+
 func (g *githubTestingPrimitives) createKubernetesResource(ctx context.Context, name string) error {
 	github := &resourcesv3.TeleportGithubConnector{
 		ObjectMeta: metav1.ObjectMeta{
@@ -95,6 +104,23 @@ func (g *githubTestingPrimitives) modifyKubernetesResource(ctx context.Context,
 	return trace.Wrap(g.setup.k8sClient.Update(ctx, github))
 }
 
+func (g *githubTestingPrimitives) createKubernetesResourceWithoutSecret(ctx context.Context, name string) error {
+	spec := githubSpec
+	spec.ClientSecret = ""
+	github := &resourcesv3.TeleportGithubConnector{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: g.setup.namespace.Name,
+		},
+		Spec: resourcesv3.TeleportGithubConnectorSpec(spec),
+	}
+	return trace.Wrap(g.setup.k8sClient.Create(ctx, github))
+}
+
+func (g *githubTestingPrimitives) secretPreserved(tResource types.GithubConnector) bool {
+	return tResource.GetClientSecret() != ""
+}
+
 func (g *githubTestingPrimitives) compareTeleportAndKubernetesResource(tResource types.GithubConnector, kResource *resourcesv3.TeleportGithubConnector) bool {
 	teleportMap, _ := teleportResourceToMap(tResource)
 	kubernetesMap, _ := teleportResourceToMap(kResource.ToTeleport())
@@ -125,3 +151,13 @@ func TestGithubConnectorUpdate(t *testing.T) {
 	test := &githubTestingPrimitives{}
 	testResourceUpdate[types.GithubConnector, *resourcesv3.TeleportGithubConnector](t, test)
 }
+
+func TestGithubConnectorOutOfBandDrift(t *testing.T) {
+	test := &githubTestingPrimitives{}
+	testResourceOutOfBandDrift[types.GithubConnector, *resourcesv3.TeleportGithubConnector](t, test)
+}
+
+func TestGithubConnectorMutator(t *testing.T) {
+	test := &githubTestingPrimitives{}
+	testResourceMutator[types.GithubConnector, *resourcesv3.TeleportGithubConnector](t, test)
+}
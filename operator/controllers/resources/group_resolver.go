@@ -0,0 +1,97 @@
+/*
+Copyright 2023 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"crypto/x509"
+	"net/url"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/operator/apis/resources"
+	"github.com/gravitational/trace"
+	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GroupResolverValidator checks the groupResolver block of a CR's spec, when present,
+// surfacing a misconfiguration (an unreachable-looking URL, a CA bundle that doesn't parse, a
+// missing expression) before the resource is synced to Teleport. It doesn't perform any
+// group resolution, and nothing else in this module does either; see the doc comment on
+// oidcGroupResolverValidator.
+type GroupResolverValidator[T types.ResourceWithOrigin] func(ctx context.Context, c kclient.Client, k8sResource kclient.Object) error
+
+// WithGroupResolverValidator overrides the default no-op GroupResolverValidator, letting a
+// resource kind validate a groupResolver block in its spec.
+func WithGroupResolverValidator[T types.ResourceWithOrigin, K TeleportKubernetesResource[T]](validate GroupResolverValidator[T]) TeleportResourceReconcilerOption[T, K] {
+	return func(r *TeleportResourceReconciler[T, K]) {
+		r.validateGroupResolver = validate
+	}
+}
+
+// validGroupResolverTokenSources are the supported values for GroupResolverConfig.TokenSource.
+var validGroupResolverTokenSources = map[string]bool{
+	"access_token": true,
+	"secretRef":    true,
+}
+
+// validateGroupResolverConfig checks cfg for an obvious misconfiguration: an unparseable or
+// non-HTTP(S) APIURL, an unsupported TokenSource, a secretRef TokenSource missing its
+// TokenSourceRef, a CABundleRef that doesn't resolve to a valid PEM bundle, or an empty
+// Expression. It does nothing when cfg is nil - a CR with no groupResolver block is valid.
+//
+// It can't go further than this: actually compiling Expression requires a JSONPath/CEL
+// library this checkout doesn't vendor, and actually probing APIURL for reachability would
+// make every reconcile depend on the IdP being up, which is a liveness problem status
+// conditions exist to report rather than avoid.
+func validateGroupResolverConfig(ctx context.Context, c kclient.Client, namespace string, cfg *resources.GroupResolverConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	parsed, err := url.Parse(cfg.APIURL)
+	if err != nil {
+		return trace.BadParameter("groupResolver.apiURL %q is not a valid URL: %v", cfg.APIURL, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return trace.BadParameter("groupResolver.apiURL %q must be http or https", cfg.APIURL)
+	}
+
+	if !validGroupResolverTokenSources[cfg.TokenSource] {
+		return trace.BadParameter("groupResolver.tokenSource %q must be one of: access_token, secretRef", cfg.TokenSource)
+	}
+	if cfg.TokenSource == "secretRef" {
+		if cfg.TokenSourceRef == nil {
+			return trace.BadParameter("groupResolver.tokenSourceRef is required when tokenSource is secretRef")
+		}
+		if _, err := resolveSecretRef(ctx, c, namespace, cfg.TokenSourceRef); err != nil {
+			return trace.Wrap(err, "resolving groupResolver.tokenSourceRef")
+		}
+	}
+
+	if cfg.CABundleRef != nil {
+		bundle, err := resolveSecretRef(ctx, c, namespace, cfg.CABundleRef)
+		if err != nil {
+			return trace.Wrap(err, "resolving groupResolver.caBundleRef")
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM([]byte(bundle)) {
+			return trace.BadParameter("groupResolver.caBundleRef does not contain a valid PEM certificate bundle")
+		}
+	}
+
+	if cfg.Expression == "" {
+		return trace.BadParameter("groupResolver.expression is required")
+	}
+
+	return nil
+}
@@ -2,68 +2,134 @@ package resources
 
 import (
 	"context"
+
 	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/auth"
 	resourcesv3 "github.com/gravitational/teleport/operator/apis/resources/v3"
+	resourcesv6 "github.com/gravitational/teleport/operator/apis/resources/v6"
 	"github.com/gravitational/teleport/operator/sidecar"
 	"github.com/gravitational/trace"
+	corev1 "k8s.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 type OIDCConnectorReconciler struct {
 	*TeleportResourceReconciler[types.OIDCConnector, *resourcesv3.TeleportOIDCConnector]
-	TeleportClientAccessor sidecar.ClientAccessor
 }
 
 func NewOIDCConnectorReconciler(client kclient.Client, accessor sidecar.ClientAccessor) *OIDCConnectorReconciler {
-	oidcReconciler := &OIDCConnectorReconciler{
-		TeleportResourceReconciler: nil,
-		TeleportClientAccessor:     accessor,
+	ops := TeleportClientOps[types.OIDCConnector]{
+		Get: func(ctx context.Context, teleportClient auth.ClientI, name string) (types.OIDCConnector, error) {
+			return teleportClient.GetOIDCConnector(ctx, name, false /* with secrets*/)
+		},
+		Upsert: func(ctx context.Context, teleportClient auth.ClientI, oidc types.OIDCConnector) error {
+			return teleportClient.UpsertOIDCConnector(ctx, oidc)
+		},
+		Delete: func(ctx context.Context, teleportClient auth.ClientI, name string) error {
+			return teleportClient.DeleteOIDCConnector(ctx, name)
+		},
 	}
 
-	resourceReconciler := NewTeleportResourceReconciler[types.OIDCConnector, *resourcesv3.TeleportOIDCConnector](
-		client,
-		oidcReconciler.GetOIDCConnector,
-		oidcReconciler.UpsertOIDCConnector,
-		oidcReconciler.UpsertOIDCConnector,
-		oidcReconciler.DeleteOIDCConnector)
-
-	oidcReconciler.TeleportResourceReconciler = resourceReconciler
-
-	return oidcReconciler
+	return &OIDCConnectorReconciler{
+		TeleportResourceReconciler: NewTeleportClientReconciler[types.OIDCConnector, *resourcesv3.TeleportOIDCConnector](
+			client, accessor, ops,
+			WithMutator[types.OIDCConnector, *resourcesv3.TeleportOIDCConnector](oidcConnectorMutator),
+			WithSecretResolver[types.OIDCConnector, *resourcesv3.TeleportOIDCConnector](oidcSecretResolver),
+			WithRoleNamesExtractor[types.OIDCConnector, *resourcesv3.TeleportOIDCConnector](oidcRoleNames),
+			WithGroupResolverValidator[types.OIDCConnector, *resourcesv3.TeleportOIDCConnector](oidcGroupResolverValidator),
+			WithSyncPeriod[types.OIDCConnector, *resourcesv3.TeleportOIDCConnector](DefaultSyncPeriod)),
+	}
 }
 
-func (r OIDCConnectorReconciler) GetOIDCConnector(ctx context.Context, name string) (types.OIDCConnector, error) {
-	teleportClient, err := r.TeleportClientAccessor(ctx)
-	if err != nil {
-		return nil, trace.Wrap(err)
+// oidcRoleNames extracts the role names connector's claims_to_roles mapping references.
+func oidcRoleNames(k8sResource kclient.Object) []string {
+	connector, ok := k8sResource.(*resourcesv3.TeleportOIDCConnector)
+	if !ok {
+		return nil
 	}
-
-	return teleportClient.GetOIDCConnector(ctx, name, false /* with secrets*/)
+	return connector.ReferencedRoleNames()
 }
 
-func (r OIDCConnectorReconciler) UpsertOIDCConnector(ctx context.Context, oidc types.OIDCConnector) error {
-	teleportClient, err := r.TeleportClientAccessor(ctx)
-	if err != nil {
-		return trace.Wrap(err)
+// oidcGroupResolverValidator checks the connector's groupResolver block, if any, is
+// well-formed. It can't do more than that: the actual group-membership fetch is meant to run on
+// the auth server at login time, through a gRPC extension point on sidecar.ClientAccessor, but
+// that extension point doesn't exist in this module yet, so there's nothing to wire this
+// validated config into beyond the status condition it produces.
+func oidcGroupResolverValidator(ctx context.Context, c kclient.Client, k8sResource kclient.Object) error {
+	connector, ok := k8sResource.(*resourcesv3.TeleportOIDCConnector)
+	if !ok {
+		return nil
 	}
+	return validateGroupResolverConfig(ctx, c, connector.Namespace, connector.Spec.GroupResolver)
+}
 
-	return teleportClient.UpsertOIDCConnector(ctx, oidc)
+// oidcConnectorMutator preserves the existing Teleport-side client secret when the CR leaves
+// client_secret unset, so the secret only has to live in Teleport (or, via clientSecretRef, in
+// a Kubernetes Secret the CR references) instead of being required inline in the CR.
+func oidcConnectorMutator(existing, desired types.OIDCConnector, exists bool) types.OIDCConnector {
+	if exists && desired.GetClientSecret() == "" {
+		desired.SetClientSecret(existing.GetClientSecret())
+	}
+	return desired
 }
 
-func (r OIDCConnectorReconciler) DeleteOIDCConnector(ctx context.Context, name string) error {
-	teleportClient, err := r.TeleportClientAccessor(ctx)
-	if err != nil {
-		return trace.Wrap(err)
+// oidcSecretResolver hydrates desired's client secret from the CR's clientSecretRef, when set,
+// overriding whatever ToTeleport() already copied from the CR's inline client_secret field.
+func oidcSecretResolver(ctx context.Context, c kclient.Client, k8sResource kclient.Object, desired types.OIDCConnector) (types.OIDCConnector, error) {
+	connector, ok := k8sResource.(*resourcesv3.TeleportOIDCConnector)
+	if !ok || connector.Spec.ClientSecretRef == nil {
+		return desired, nil
 	}
 
-	return teleportClient.DeleteOIDCConnector(ctx, name)
+	secret, err := resolveSecretRef(ctx, c, connector.Namespace, connector.Spec.ClientSecretRef)
+	if err != nil {
+		return desired, trace.Wrap(err)
+	}
+	desired.SetClientSecret(secret)
+	return desired, nil
 }
 
 func (r OIDCConnectorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	return r.Do(ctx, req, &resourcesv3.TeleportOIDCConnector{})
 }
 
+// +kubebuilder:rbac:groups=resources.teleport.dev,resources=teleportoidcconnectors,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=resources.teleport.dev,resources=teleportoidcconnectors/status,verbs=get;update;patch
+
 func (r OIDCConnectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).For(&resourcesv3.TeleportOIDCConnector{}).Complete(r)
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&resourcesv3.TeleportOIDCConnector{}).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(watchReferencingSecrets(
+				r.Client,
+				func() *resourcesv3.TeleportOIDCConnectorList { return &resourcesv3.TeleportOIDCConnectorList{} },
+				func(list *resourcesv3.TeleportOIDCConnectorList) []secretReferencingObject {
+					items := make([]secretReferencingObject, len(list.Items))
+					for i := range list.Items {
+						items[i] = &list.Items[i]
+					}
+					return items
+				},
+			)),
+		).
+		Watches(
+			&resourcesv6.TeleportRole{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj kclient.Object) []reconcile.Request {
+				role, ok := obj.(*resourcesv6.TeleportRole)
+				if !ok {
+					return nil
+				}
+				keys := r.roleIndex.dependentsOf(role.GetName())
+				requests := make([]reconcile.Request, len(keys))
+				for i, key := range keys {
+					requests[i] = reconcile.Request{NamespacedName: key}
+				}
+				return requests
+			}),
+		).
+		Complete(r)
 }
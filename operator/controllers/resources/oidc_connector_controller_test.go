@@ -54,6 +54,15 @@ func (g *oidcTestingPrimitives) deleteTeleportResource(ctx context.Context, name
 	return trace.Wrap(g.setup.tClient.DeleteOIDCConnector(ctx, name))
 }
 
+func (g *oidcTestingPrimitives) modifyTeleportResource(ctx context.Context, name string) error {
+	oidc, err := g.setup.tClient.GetOIDCConnector(ctx, name, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	oidc.SetClientID("modified out-of-band")
+	return trace.Wrap(g.setup.tClient.UpsertOIDCConnector(ctx, oidc))
+}
+
 func (g *oidcTestingPrimitives) createKubernetesResource(ctx context.Context, name string) error {
 	oidc := &resourcesv3.TeleportOIDCConnector{
 		ObjectMeta: metav1.ObjectMeta{
@@ -94,6 +103,23 @@ func (g *oidcTestingPrimitives) modifyKubernetesResource(ctx context.Context, na
 	return g.setup.k8sClient.Update(ctx, oidc)
 }
 
+func (g *oidcTestingPrimitives) createKubernetesResourceWithoutSecret(ctx context.Context, name string) error {
+	spec := oidcSpec
+	spec.ClientSecret = ""
+	oidc := &resourcesv3.TeleportOIDCConnector{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: g.setup.namespace.Name,
+		},
+		Spec: resourcesv3.TeleportOIDCConnectorSpec(spec),
+	}
+	return trace.Wrap(g.setup.k8sClient.Create(ctx, oidc))
+}
+
+func (g *oidcTestingPrimitives) secretPreserved(tResource types.OIDCConnector) bool {
+	return tResource.GetClientSecret() != ""
+}
+
 func (g *oidcTestingPrimitives) compareTeleportAndKubernetesResource(tResource types.OIDCConnector, kResource *resourcesv3.TeleportOIDCConnector) bool {
 	teleportMap, _ := teleportResourceToMap(tResource)
 	kubernetesMap, _ := teleportResourceToMap(kResource.ToTeleport())
@@ -124,3 +150,13 @@ func TestOIDCConnectorUpdate(t *testing.T) {
 	test := &oidcTestingPrimitives{}
 	testResourceUpdate[types.OIDCConnector, *resourcesv3.TeleportOIDCConnector](t, test)
 }
+
+func TestOIDCConnectorOutOfBandDrift(t *testing.T) {
+	test := &oidcTestingPrimitives{}
+	testResourceOutOfBandDrift[types.OIDCConnector, *resourcesv3.TeleportOIDCConnector](t, test)
+}
+
+func TestOIDCConnectorMutator(t *testing.T) {
+	test := &oidcTestingPrimitives{}
+	testResourceMutator[types.OIDCConnector, *resourcesv3.TeleportOIDCConnector](t, test)
+}
@@ -0,0 +1,76 @@
+package resources
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// DeletionFinalizer is added to every Teleport-backed CR so the operator gets a chance to
+// clean up the corresponding Teleport resource before Kubernetes garbage collects the CR.
+const DeletionFinalizer = "resources.teleport.dev/deletion"
+
+// ResourceBaseReconciler reconciles a Kubernetes CR against a Teleport resource. Per-kind
+// reconcilers (OIDCConnectorReconciler, etc.) embed this type and supply UpsertExternal and
+// DeleteExternal, which is the only place they need to know about Teleport at all.
+type ResourceBaseReconciler struct {
+	Client client.Client
+
+	// UpsertExternal creates or updates the Teleport resource for obj.
+	UpsertExternal func(ctx context.Context, obj client.Object) (ctrl.Result, error)
+	// DeleteExternal deletes the Teleport resource for obj.
+	DeleteExternal func(ctx context.Context, obj client.Object) (ctrl.Result, error)
+}
+
+// Do fetches obj and drives it through finalizer-protected create/update/delete against
+// Teleport, returning a typed ctrl.Result so callers can distinguish "done", "retry later",
+// and "give up".
+func (r *ResourceBaseReconciler) Do(ctx context.Context, req ctrl.Request, obj client.Object) (ctrl.Result, error) {
+	if err := r.Client.Get(ctx, req.NamespacedName, obj); err != nil {
+		if kerrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, trace.Wrap(err)
+	}
+
+	if obj.GetDeletionTimestamp().IsZero() {
+		if !controllerutil.ContainsFinalizer(obj, DeletionFinalizer) {
+			controllerutil.AddFinalizer(obj, DeletionFinalizer)
+			if err := r.Client.Update(ctx, obj); err != nil {
+				return ctrl.Result{}, trace.Wrap(err)
+			}
+		}
+		return r.UpsertExternal(ctx, obj)
+	}
+
+	if !controllerutil.ContainsFinalizer(obj, DeletionFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	result, err := r.DeleteExternal(ctx, obj)
+	if err != nil {
+		return result, trace.Wrap(err)
+	}
+
+	controllerutil.RemoveFinalizer(obj, DeletionFinalizer)
+	if err := r.Client.Update(ctx, obj); err != nil {
+		return ctrl.Result{}, trace.Wrap(err)
+	}
+	return ctrl.Result{}, nil
+}
+
+// inProgressRequeueInterval is how soon we check back on a resource we expect to still be
+// reconciling downstream (e.g. a Teleport-side resource still propagating to other auth
+// servers).
+const inProgressRequeueInterval = 2 * time.Second
+
+// Status returns a writer for the status subresource, so embedders can persist status
+// conditions without reaching into r.Client themselves.
+func (r *ResourceBaseReconciler) Status() client.StatusWriter {
+	return r.Client.Status()
+}
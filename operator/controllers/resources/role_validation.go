@@ -0,0 +1,124 @@
+/*
+Copyright 2023 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gravitational/teleport/api/types"
+	resourcesv6 "github.com/gravitational/teleport/operator/apis/resources/v6"
+	"github.com/gravitational/trace"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=resources.teleport.dev,resources=teleportroles,verbs=get;list;watch
+
+// RoleNamesExtractor returns every Teleport role name a CR's spec references (e.g. via
+// claims_to_roles, teams_to_roles, or attributes_to_roles), so the reconciler can validate
+// they exist before upserting. Defaults to a no-op returning nil for resource kinds that
+// don't map anything to Teleport roles.
+type RoleNamesExtractor func(k8sResource kclient.Object) []string
+
+// WithRoleNamesExtractor overrides the default no-op RoleNamesExtractor, letting a resource
+// kind validate the role names its spec references against the roles known to the cluster.
+func WithRoleNamesExtractor[T types.ResourceWithOrigin, K TeleportKubernetesResource[T]](extract RoleNamesExtractor) TeleportResourceReconcilerOption[T, K] {
+	return func(r *TeleportResourceReconciler[T, K]) {
+		r.roleNames = extract
+	}
+}
+
+// validateRoleNames checks every name in roleNames against roleExists (the Teleport server's
+// role store) and, failing that, against the TeleportRole CRs in namespace, returning the
+// names that exist in neither place. A role declared as a TeleportRole CR but not yet synced
+// to Teleport still counts as resolved, since the CR's own reconciler (once it exists) is
+// responsible for getting it there.
+func validateRoleNames(ctx context.Context, c kclient.Client, roleExists func(context.Context, string) (bool, error), namespace string, roleNames []string) ([]string, error) {
+	var missing []string
+	seen := make(map[string]bool, len(roleNames))
+
+	for _, name := range roleNames {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		exists, err := roleExists(ctx, name)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if exists {
+			continue
+		}
+
+		var role resourcesv6.TeleportRole
+		err = c.Get(ctx, kclient.ObjectKey{Namespace: namespace, Name: name}, &role)
+		if err == nil {
+			continue
+		}
+		if !kerrors.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+		missing = append(missing, name)
+	}
+
+	return missing, nil
+}
+
+// roleDependencyIndex tracks, per Teleport role name, the connector CRs whose spec
+// references it, so that creating a previously-missing TeleportRole CR can re-enqueue the
+// connectors that were waiting on it instead of leaving them stuck until their next periodic
+// resync. It's process-local and empties on restart, same tradeoff as revisionTracker.
+type roleDependencyIndex struct {
+	mu sync.Mutex
+	// dependents maps a role name to the connector CRs that reference it.
+	dependents map[string]map[kclient.ObjectKey]struct{}
+}
+
+func newRoleDependencyIndex() *roleDependencyIndex {
+	return &roleDependencyIndex{dependents: make(map[string]map[kclient.ObjectKey]struct{})}
+}
+
+// set replaces the role names connector references with roleNames, dropping any stale
+// membership from roles it no longer references.
+func (idx *roleDependencyIndex) set(connector kclient.ObjectKey, roleNames []string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for role, connectors := range idx.dependents {
+		delete(connectors, connector)
+		if len(connectors) == 0 {
+			delete(idx.dependents, role)
+		}
+	}
+	for _, role := range roleNames {
+		if idx.dependents[role] == nil {
+			idx.dependents[role] = make(map[kclient.ObjectKey]struct{})
+		}
+		idx.dependents[role][connector] = struct{}{}
+	}
+}
+
+// dependentsOf returns every connector CR known to reference roleName.
+func (idx *roleDependencyIndex) dependentsOf(roleName string) []kclient.ObjectKey {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	keys := make([]kclient.ObjectKey, 0, len(idx.dependents[roleName]))
+	for key := range idx.dependents[roleName] {
+		keys = append(keys, key)
+	}
+	return keys
+}
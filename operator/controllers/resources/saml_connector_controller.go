@@ -2,68 +2,168 @@ package resources
 
 import (
 	"context"
+
 	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/auth"
 	resourcesv2 "github.com/gravitational/teleport/operator/apis/resources/v2"
+	resourcesv6 "github.com/gravitational/teleport/operator/apis/resources/v6"
 	"github.com/gravitational/teleport/operator/sidecar"
 	"github.com/gravitational/trace"
+	corev1 "k8s.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 type SAMLConnectorReconciler struct {
 	*TeleportResourceReconciler[types.SAMLConnector, *resourcesv2.TeleportSAMLConnector]
-	TeleportClientAccessor sidecar.ClientAccessor
 }
 
 func NewSAMLConnectorReconciler(client kclient.Client, accessor sidecar.ClientAccessor) *SAMLConnectorReconciler {
-	oidcReconciler := &SAMLConnectorReconciler{
-		TeleportResourceReconciler: nil,
-		TeleportClientAccessor:     accessor,
+	ops := TeleportClientOps[types.SAMLConnector]{
+		Get: func(ctx context.Context, teleportClient auth.ClientI, name string) (types.SAMLConnector, error) {
+			return teleportClient.GetSAMLConnector(ctx, name, false /* with secrets*/)
+		},
+		Upsert: func(ctx context.Context, teleportClient auth.ClientI, oidc types.SAMLConnector) error {
+			return teleportClient.UpsertSAMLConnector(ctx, oidc)
+		},
+		Delete: func(ctx context.Context, teleportClient auth.ClientI, name string) error {
+			return teleportClient.DeleteSAMLConnector(ctx, name)
+		},
 	}
 
-	resourceReconciler := NewTeleportResourceReconciler[types.SAMLConnector, *resourcesv2.TeleportSAMLConnector](
-		client,
-		oidcReconciler.GetSAMLConnector,
-		oidcReconciler.UpsertSAMLConnector,
-		oidcReconciler.UpsertSAMLConnector,
-		oidcReconciler.DeleteSAMLConnector)
-
-	oidcReconciler.TeleportResourceReconciler = resourceReconciler
+	return &SAMLConnectorReconciler{
+		TeleportResourceReconciler: NewTeleportClientReconciler[types.SAMLConnector, *resourcesv2.TeleportSAMLConnector](
+			client, accessor, ops,
+			WithMutator[types.SAMLConnector, *resourcesv2.TeleportSAMLConnector](samlConnectorMutator),
+			WithSecretResolver[types.SAMLConnector, *resourcesv2.TeleportSAMLConnector](samlSecretResolver),
+			WithRoleNamesExtractor[types.SAMLConnector, *resourcesv2.TeleportSAMLConnector](samlRoleNames),
+			WithGroupResolverValidator[types.SAMLConnector, *resourcesv2.TeleportSAMLConnector](samlGroupResolverValidator),
+			WithSyncPeriod[types.SAMLConnector, *resourcesv2.TeleportSAMLConnector](DefaultSyncPeriod)),
+	}
+}
 
-	return oidcReconciler
+// samlRoleNames extracts the role names connector's attributes_to_roles mapping references.
+func samlRoleNames(k8sResource kclient.Object) []string {
+	connector, ok := k8sResource.(*resourcesv2.TeleportSAMLConnector)
+	if !ok {
+		return nil
+	}
+	return connector.ReferencedRoleNames()
 }
 
-func (r SAMLConnectorReconciler) GetSAMLConnector(ctx context.Context, name string) (types.SAMLConnector, error) {
-	teleportClient, err := r.TeleportClientAccessor(ctx)
-	if err != nil {
-		return nil, trace.Wrap(err)
+// samlGroupResolverValidator checks the connector's groupResolver block, if any, is
+// well-formed. See oidcGroupResolverValidator for why this stops at validation instead of
+// wiring the config into an actual login-time group fetch.
+func samlGroupResolverValidator(ctx context.Context, c kclient.Client, k8sResource kclient.Object) error {
+	connector, ok := k8sResource.(*resourcesv2.TeleportSAMLConnector)
+	if !ok {
+		return nil
 	}
+	return validateGroupResolverConfig(ctx, c, connector.Namespace, connector.Spec.GroupResolver)
+}
 
-	return teleportClient.GetSAMLConnector(ctx, name, false /* with secrets*/)
+// samlConnectorMutator preserves the signing key pair Teleport generates for a SAML
+// connector when the CR leaves signing_key_pair unset: a blind Upsert of the CR-derived
+// resource would otherwise wipe it out and force Teleport to mint a new one on every sync. A CR that
+// sources its own signing key via signingKeyPairRef still wins, since samlSecretResolver runs
+// before this mutator and desired.GetSigningKeyPair() is then already non-nil.
+func samlConnectorMutator(existing, desired types.SAMLConnector, exists bool) types.SAMLConnector {
+	if exists && desired.GetSigningKeyPair() == nil {
+		if keyPair := existing.GetSigningKeyPair(); keyPair != nil {
+			desired.SetSigningKeyPair(keyPair)
+		}
+	}
+	return desired
 }
 
-func (r SAMLConnectorReconciler) UpsertSAMLConnector(ctx context.Context, oidc types.SAMLConnector) error {
-	teleportClient, err := r.TeleportClientAccessor(ctx)
-	if err != nil {
-		return trace.Wrap(err)
+// samlSecretResolver hydrates desired's entity descriptor and signing/encryption key pairs
+// from the CR's *Ref fields, when set, so that IdP metadata and key material can live in a
+// Kubernetes Secret instead of the CR's plaintext spec - analogous to how external SSO
+// connectors (e.g. the dex CloudFoundry connector) accept their key material from out-of-band
+// sources rather than the connector config blob.
+func samlSecretResolver(ctx context.Context, c kclient.Client, k8sResource kclient.Object, desired types.SAMLConnector) (types.SAMLConnector, error) {
+	connector, ok := k8sResource.(*resourcesv2.TeleportSAMLConnector)
+	if !ok {
+		return desired, nil
 	}
 
-	return teleportClient.UpsertSAMLConnector(ctx, oidc)
-}
+	if connector.Spec.EntityDescriptorRef != nil {
+		entityDescriptor, err := resolveSecretRef(ctx, c, connector.Namespace, connector.Spec.EntityDescriptorRef)
+		if err != nil {
+			return desired, trace.Wrap(err)
+		}
+		desired.SetEntityDescriptor(entityDescriptor)
+	}
 
-func (r SAMLConnectorReconciler) DeleteSAMLConnector(ctx context.Context, name string) error {
-	teleportClient, err := r.TeleportClientAccessor(ctx)
-	if err != nil {
-		return trace.Wrap(err)
+	if connector.Spec.SigningKeyPairRef != nil {
+		privateKey, err := resolveSecretRef(ctx, c, connector.Namespace, connector.Spec.SigningKeyPairRef)
+		if err != nil {
+			return desired, trace.Wrap(err)
+		}
+		keyPair := desired.GetSigningKeyPair()
+		if keyPair == nil {
+			keyPair = &types.AsymmetricKeyPair{}
+		}
+		keyPair.PrivateKey = privateKey
+		desired.SetSigningKeyPair(keyPair)
 	}
 
-	return teleportClient.DeleteSAMLConnector(ctx, name)
+	if connector.Spec.EncryptionKeyPairRef != nil {
+		privateKey, err := resolveSecretRef(ctx, c, connector.Namespace, connector.Spec.EncryptionKeyPairRef)
+		if err != nil {
+			return desired, trace.Wrap(err)
+		}
+		keyPair := desired.GetEncryptionKeyPair()
+		if keyPair == nil {
+			keyPair = &types.AsymmetricKeyPair{}
+		}
+		keyPair.PrivateKey = privateKey
+		desired.SetEncryptionKeyPair(keyPair)
+	}
+
+	return desired, nil
 }
 
 func (r SAMLConnectorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	return r.Do(ctx, req, &resourcesv2.TeleportSAMLConnector{})
 }
 
+// +kubebuilder:rbac:groups=resources.teleport.dev,resources=teleportsamlconnectors,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=resources.teleport.dev,resources=teleportsamlconnectors/status,verbs=get;update;patch
+
 func (r SAMLConnectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).For(&resourcesv2.TeleportSAMLConnector{}).Complete(r)
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&resourcesv2.TeleportSAMLConnector{}).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(watchReferencingSecrets(
+				r.Client,
+				func() *resourcesv2.TeleportSAMLConnectorList { return &resourcesv2.TeleportSAMLConnectorList{} },
+				func(list *resourcesv2.TeleportSAMLConnectorList) []secretReferencingObject {
+					items := make([]secretReferencingObject, len(list.Items))
+					for i := range list.Items {
+						items[i] = &list.Items[i]
+					}
+					return items
+				},
+			)),
+		).
+		Watches(
+			&resourcesv6.TeleportRole{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj kclient.Object) []reconcile.Request {
+				role, ok := obj.(*resourcesv6.TeleportRole)
+				if !ok {
+					return nil
+				}
+				keys := r.roleIndex.dependentsOf(role.GetName())
+				requests := make([]reconcile.Request, len(keys))
+				for i, key := range keys {
+					requests[i] = reconcile.Request{NamespacedName: key}
+				}
+				return requests
+			}),
+		).
+		Complete(r)
 }
@@ -60,6 +60,15 @@ func (g *samlTestingPrimitives) deleteTeleportResource(ctx context.Context, name
 	return trace.Wrap(g.setup.tClient.DeleteSAMLConnector(ctx, name))
 }
 
+func (g *samlTestingPrimitives) modifyTeleportResource(ctx context.Context, name string) error {
+	saml, err := g.setup.tClient.GetSAMLConnector(ctx, name, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	saml.SetIssuer("modified-out-of-band")
+	return trace.Wrap(g.setup.tClient.UpsertSAMLConnector(ctx, saml))
+}
+
 func (g *samlTestingPrimitives) createKubernetesResource(ctx context.Context, name string) error {
 	saml := &resourcesv2.TeleportSAMLConnector{
 		ObjectMeta: metav1.ObjectMeta{
@@ -100,6 +109,16 @@ func (g *samlTestingPrimitives) modifyKubernetesResource(ctx context.Context, na
 	return trace.Wrap(g.setup.k8sClient.Update(ctx, saml))
 }
 
+// createKubernetesResourceWithoutSecret is identical to createKubernetesResource here:
+// samlSpec already leaves signing_key_pair unset, so there's nothing to omit.
+func (g *samlTestingPrimitives) createKubernetesResourceWithoutSecret(ctx context.Context, name string) error {
+	return g.createKubernetesResource(ctx, name)
+}
+
+func (g *samlTestingPrimitives) secretPreserved(tResource types.SAMLConnector) bool {
+	return tResource.GetSigningKeyPair() != nil
+}
+
 func (g *samlTestingPrimitives) compareTeleportAndKubernetesResource(tResource types.SAMLConnector, kResource *resourcesv2.TeleportSAMLConnector) bool {
 	teleportMap, _ := teleportResourceToMap(tResource)
 	kubernetesMap, _ := teleportResourceToMap(kResource.ToTeleport())
@@ -133,3 +152,13 @@ func TestSAMLConnectorUpdate(t *testing.T) {
 	test := &samlTestingPrimitives{}
 	testResourceUpdate[types.SAMLConnector, *resourcesv2.TeleportSAMLConnector](t, test)
 }
+
+func TestSAMLConnectorOutOfBandDrift(t *testing.T) {
+	test := &samlTestingPrimitives{}
+	testResourceOutOfBandDrift[types.SAMLConnector, *resourcesv2.TeleportSAMLConnector](t, test)
+}
+
+func TestSAMLConnectorMutator(t *testing.T) {
+	test := &samlTestingPrimitives{}
+	testResourceMutator[types.SAMLConnector, *resourcesv2.TeleportSAMLConnector](t, test)
+}
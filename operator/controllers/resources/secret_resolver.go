@@ -0,0 +1,90 @@
+/*
+Copyright 2023 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+
+	"github.com/gravitational/teleport/operator/apis/resources"
+	"github.com/gravitational/trace"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+
+// resolveSecretRef reads ref's key out of the Secret named by ref, in namespace, returning ""
+// when ref is nil so a CR that doesn't use a secretRef field behaves exactly as it did before
+// secretRef fields existed. A missing Secret or key is reported as trace.NotFound so callers
+// can distinguish "not configured" from a transient lookup failure.
+func resolveSecretRef(ctx context.Context, c kclient.Client, namespace string, ref *resources.SecretRef) (string, error) {
+	if ref == nil {
+		return "", nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, kclient.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		if kerrors.IsNotFound(err) {
+			return "", trace.NotFound("secret %s/%s not found", namespace, ref.Name)
+		}
+		return "", trace.Wrap(err, "resolving secretRef %s/%s", namespace, ref.Name)
+	}
+
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", trace.NotFound("key %q not found in secret %s/%s", ref.Key, namespace, ref.Name)
+	}
+	return string(value), nil
+}
+
+// secretReferencingObject is implemented by every CR whose spec can source a sensitive field
+// from a Secret, so watchReferencingSecrets can find the CRs a changed Secret affects without
+// each resource kind reimplementing the same List-and-filter loop.
+type secretReferencingObject interface {
+	kclient.Object
+	// ReferencesSecret reports whether the CR's spec references the named Secret.
+	ReferencesSecret(name string) bool
+}
+
+// watchReferencingSecrets builds a handler.MapFunc for use with ctrl.Builder.Watches: given a
+// changed Secret, it lists every CR in the Secret's namespace via newList and requeues the
+// ones whose ReferencesSecret reports true for that Secret's name, so rotating a Secret (e.g.
+// a client_secret) triggers reconciliation of the connector CRs that source a field from it.
+// items adapts newList's concrete *TeleportFooConnectorList into the []secretReferencingObject
+// this function operates on, since Go generics can't range over a List's Items field
+// generically.
+func watchReferencingSecrets[L kclient.ObjectList](c kclient.Client, newList func() L, items func(L) []secretReferencingObject) handler.MapFunc {
+	return func(ctx context.Context, obj kclient.Object) []reconcile.Request {
+		secret, ok := obj.(*corev1.Secret)
+		if !ok {
+			return nil
+		}
+
+		list := newList()
+		if err := c.List(ctx, list, kclient.InNamespace(secret.GetNamespace())); err != nil {
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, item := range items(list) {
+			if item.ReferencesSecret(secret.GetName()) {
+				requests = append(requests, reconcile.Request{NamespacedName: kclient.ObjectKeyFromObject(item)})
+			}
+		}
+		return requests
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2023 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/operator/sidecar"
+	"github.com/gravitational/trace"
+	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TeleportClientOps are the auth.ClientI calls needed to reconcile a single Teleport
+// resource kind. Every connector/resource reconciler (OIDC, GitHub, SAML, ...) supplies
+// these three closures plus its own TeleportResourceReconcilerOptions; the client lookup is
+// handled by NewTeleportClientReconciler.
+type TeleportClientOps[T types.ResourceWithOrigin] struct {
+	Get    func(ctx context.Context, teleportClient auth.ClientI, name string) (T, error)
+	Upsert func(ctx context.Context, teleportClient auth.ClientI, resource T) error
+	Delete func(ctx context.Context, teleportClient auth.ClientI, name string) error
+}
+
+// NewTeleportClientReconciler builds a TeleportResourceReconciler that resolves the
+// Teleport client from accessor once per call and forwards to ops, removing the
+// copy-pasted GetFoo/UpsertFoo/DeleteFoo boilerplate every resource kind used to need.
+func NewTeleportClientReconciler[T types.ResourceWithOrigin, K TeleportKubernetesResource[T]](
+	client kclient.Client,
+	accessor sidecar.ClientAccessor,
+	ops TeleportClientOps[T],
+	opts ...TeleportResourceReconcilerOption[T, K],
+) *TeleportResourceReconciler[T, K] {
+	get := func(ctx context.Context, name string) (resource T, err error) {
+		teleportClient, err := accessor(ctx)
+		if err != nil {
+			return resource, trace.Wrap(err)
+		}
+		return ops.Get(ctx, teleportClient, name)
+	}
+	upsert := func(ctx context.Context, resource T) error {
+		teleportClient, err := accessor(ctx)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(ops.Upsert(ctx, teleportClient, resource))
+	}
+	del := func(ctx context.Context, name string) error {
+		teleportClient, err := accessor(ctx)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(ops.Delete(ctx, teleportClient, name))
+	}
+
+	reconciler := NewTeleportResourceReconciler[T, K](client, get, upsert, upsert, del, opts...)
+	// roleExists checks the Teleport server directly, independent of T: a role referenced by
+	// claims_to_roles/teams_to_roles/attributes_to_roles is looked up the same way regardless
+	// of which connector kind is doing the looking.
+	reconciler.roleExists = func(ctx context.Context, name string) (bool, error) {
+		teleportClient, err := accessor(ctx)
+		if err != nil {
+			return false, trace.Wrap(err)
+		}
+		_, err = teleportClient.GetRole(ctx, name)
+		if err != nil {
+			if trace.IsNotFound(err) {
+				return false, nil
+			}
+			return false, trace.Wrap(err)
+		}
+		return true, nil
+	}
+	return reconciler
+}
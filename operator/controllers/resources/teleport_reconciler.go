@@ -3,10 +3,15 @@ package resources
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/gravitational/teleport/api/types"
 	"github.com/gravitational/trace"
 	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
 	kclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -22,6 +27,100 @@ type TeleportResourceReconciler[T types.ResourceWithOrigin, K TeleportKubernetes
 	UpdateTeleportResource UpdateTeleportResource[T]
 	CreateTeleportResource CreateTeleportResource[T]
 	DeleteTeleportResource DeleteTeleportResource
+
+	// backoff tracks consecutive transient failures per resource so retries back off
+	// exponentially instead of hammering an unreachable Teleport auth server. It is
+	// process-local and resets on restart.
+	backoff transientFailureBackoff
+
+	// syncPeriod, when non-zero, makes Upsert requeue every owned CR on this interval even
+	// without a Kubernetes-side event, so out-of-band changes made directly against
+	// Teleport are noticed and corrected. Set via WithSyncPeriod.
+	syncPeriod time.Duration
+
+	// drift remembers the Teleport resource revision last observed right after a
+	// successful reconcile, so the next sync can tell "changed by us" from "changed
+	// out-of-band" and surface DriftDetected accordingly. Process-local, like backoff.
+	drift revisionTracker
+
+	// mutate merges the existing Teleport resource into the one derived from the CR before
+	// every create/update, so server-owned fields survive a Kubernetes-originated sync.
+	// Defaults to a passthrough that just returns the desired resource unchanged; override
+	// with WithMutator.
+	mutate Mutator[T]
+
+	// resolveSecrets hydrates secretRef-sourced fields (e.g. an OIDC client secret) into the
+	// resource derived from the CR, reading the referenced Secret via r.Client. Defaults to a
+	// passthrough that does nothing, for resource kinds with no secretRef fields yet; override
+	// with WithSecretResolver.
+	resolveSecrets SecretResolver[T]
+
+	// roleNames extracts the Teleport role names the CR's spec references (e.g. via
+	// claims_to_roles), so Upsert can validate they exist before syncing. Defaults to a
+	// no-op returning nil, for resource kinds with no role-mapping fields; override with
+	// WithRoleNamesExtractor.
+	roleNames RoleNamesExtractor
+
+	// roleExists reports whether name is a role on the Teleport server. Set internally by
+	// NewTeleportClientReconciler; defaults to always-false here so a CR-only fallback still
+	// works for a reconciler built directly through NewTeleportResourceReconciler.
+	roleExists func(ctx context.Context, name string) (bool, error)
+
+	// roleIndex tracks which connector CRs reference which role names, so creating a
+	// previously-missing TeleportRole CR can re-enqueue the connectors waiting on it.
+	roleIndex *roleDependencyIndex
+
+	// validateGroupResolver checks the CR's groupResolver block, if any, for a
+	// misconfiguration. Defaults to a no-op, for resource kinds with no groupResolver field;
+	// override with WithGroupResolverValidator.
+	validateGroupResolver GroupResolverValidator[T]
+}
+
+// Mutator merges the current Teleport-side resource with the one derived from the
+// Kubernetes spec, returning what should actually be upserted. It lets default mutators
+// preserve server-owned fields (CreatedBy, LastUsed, signing key material, ...) that
+// ToTeleport() never sets and that a blind full-spec Upsert would otherwise clobber.
+// existing is the zero value of T when the resource doesn't exist yet (exists is false).
+type Mutator[T types.ResourceWithOrigin] func(existing, desired T, exists bool) T
+
+// WithMutator overrides the default passthrough Mutator, letting a resource kind preserve
+// fields that only ever get set on the Teleport side.
+func WithMutator[T types.ResourceWithOrigin, K TeleportKubernetesResource[T]](mutate Mutator[T]) TeleportResourceReconcilerOption[T, K] {
+	return func(r *TeleportResourceReconciler[T, K]) {
+		r.mutate = mutate
+	}
+}
+
+// SecretResolver hydrates desired (the resource ToTeleport() derived from the CR) with
+// values read out of Kubernetes Secrets the CR's secretRef fields point at, given k8sResource
+// for its namespace and spec. It runs before mutate, so a resolved secret still participates
+// in mutate's existing-vs-desired merge the same way an inlined one would.
+type SecretResolver[T types.ResourceWithOrigin] func(ctx context.Context, c kclient.Client, k8sResource kclient.Object, desired T) (T, error)
+
+// WithSecretResolver overrides the default no-op SecretResolver, letting a resource kind
+// source sensitive spec fields from a referenced Kubernetes Secret instead of requiring them
+// inline in the CR.
+func WithSecretResolver[T types.ResourceWithOrigin, K TeleportKubernetesResource[T]](resolve SecretResolver[T]) TeleportResourceReconcilerOption[T, K] {
+	return func(r *TeleportResourceReconciler[T, K]) {
+		r.resolveSecrets = resolve
+	}
+}
+
+// TeleportResourceReconcilerOption customizes a TeleportResourceReconciler built by
+// NewTeleportResourceReconciler.
+type TeleportResourceReconcilerOption[T types.ResourceWithOrigin, K TeleportKubernetesResource[T]] func(*TeleportResourceReconciler[T, K])
+
+// DefaultSyncPeriod is the WithSyncPeriod interval every connector reconciler is built with
+// in production, absent a more specific need to override it.
+const DefaultSyncPeriod = 10 * time.Minute
+
+// WithSyncPeriod enables periodic out-of-band drift detection: every period, each owned CR
+// is re-reconciled even if nothing changed in Kubernetes, so Teleport-side edits or deletions
+// are caught and corrected instead of waiting for the next Kubernetes event.
+func WithSyncPeriod[T types.ResourceWithOrigin, K TeleportKubernetesResource[T]](period time.Duration) TeleportResourceReconcilerOption[T, K] {
+	return func(r *TeleportResourceReconciler[T, K]) {
+		r.syncPeriod = period
+	}
 }
 
 type GetTeleportResource[T types.Resource] func(context.Context, string) (T, error)
@@ -34,7 +133,8 @@ func NewTeleportResourceReconciler[T types.ResourceWithOrigin, K TeleportKuberne
 	get GetTeleportResource[T],
 	update UpdateTeleportResource[T],
 	create CreateTeleportResource[T],
-	delete DeleteTeleportResource) *TeleportResourceReconciler[T, K] {
+	delete DeleteTeleportResource,
+	opts ...TeleportResourceReconcilerOption[T, K]) *TeleportResourceReconciler[T, K] {
 
 	reconciler := &TeleportResourceReconciler[T, K]{
 		ResourceBaseReconciler: ResourceBaseReconciler{Client: client},
@@ -42,57 +142,343 @@ func NewTeleportResourceReconciler[T types.ResourceWithOrigin, K TeleportKuberne
 		UpdateTeleportResource: update,
 		CreateTeleportResource: create,
 		DeleteTeleportResource: delete,
+		backoff:                newTransientFailureBackoff(time.Second, time.Minute),
+		drift:                  newRevisionTracker(),
+		mutate:                 func(existing, desired T, exists bool) T { return desired },
+		resolveSecrets:         func(ctx context.Context, c kclient.Client, k8sResource kclient.Object, desired T) (T, error) { return desired, nil },
+		roleNames:              func(k8sResource kclient.Object) []string { return nil },
+		roleExists:             func(ctx context.Context, name string) (bool, error) { return false, nil },
+		roleIndex:              newRoleDependencyIndex(),
+		validateGroupResolver:  func(ctx context.Context, c kclient.Client, k8sResource kclient.Object) error { return nil },
+	}
+	for _, opt := range opts {
+		opt(reconciler)
 	}
 	reconciler.ResourceBaseReconciler.UpsertExternal = reconciler.Upsert
 	reconciler.ResourceBaseReconciler.DeleteExternal = reconciler.Delete
 	return reconciler
 }
 
-func (r TeleportResourceReconciler[T, K]) Upsert(ctx context.Context, obj kclient.Object) error {
+func (r *TeleportResourceReconciler[T, K]) Upsert(ctx context.Context, obj kclient.Object) (ctrl.Result, error) {
 	k8sResource, ok := obj.(K)
 	if !ok {
-		return fmt.Errorf("failed to convert Object into resource object: %T", obj)
+		return ctrl.Result{}, fmt.Errorf("failed to convert Object into resource object: %T", obj)
 	}
+	name := kclient.ObjectKeyFromObject(obj).String()
 	teleportResource := k8sResource.ToTeleport()
 
+	// Resolve secretRef fields (e.g. an OIDC client secret sourced from a Secret) before
+	// diffing against the server-side resource, so a rotation in the Secret is treated the
+	// same as an operator editing the field inline would be.
+	teleportResource, secretErr := r.resolveSecrets(ctx, r.Client, obj, teleportResource)
+	meta.SetStatusCondition(k8sResource.StatusConditions(), getSecretsResolvedCondition(secretErr))
+
 	existingResource, err := r.GetTeleportResource(ctx, teleportResource.GetName())
 	if err != nil && !trace.IsNotFound(err) {
-		return trace.Wrap(err)
+		return r.handleResultAndError(name, err)
 	}
 	exists := !trace.IsNotFound(err)
 
-	newOwnershipCondition, err := checkOwnership(existingResource)
-	// Setting the condition before returning a potential ownership error
-	meta.SetStatusCondition(k8sResource.StatusConditions(), newOwnershipCondition)
-	if err != nil {
-		silentUpdateStatus(ctx, r.Client, k8sResource)
-		return trace.Wrap(err)
+	if exists {
+		meta.SetStatusCondition(k8sResource.StatusConditions(), r.drift.check(name, existingResource.GetRevision()))
+	}
+
+	// Each phase below is independent: a failure in one doesn't stop the others from
+	// running and reporting their own condition, so a reader only ever sees one missing
+	// piece of the picture instead of a single opaque error hiding a second, unrelated one.
+	var errs []error
+
+	if secretErr != nil {
+		errs = append(errs, secretErr)
 	}
 
-	if err != nil {
-		return trace.Wrap(err)
+	// Validate the Teleport role names the CR's spec references (e.g. via claims_to_roles)
+	// before syncing, so a typo'd or not-yet-created role is caught here instead of being
+	// silently accepted by Teleport's own, more permissive UpsertFoo call. The dependency
+	// index is updated unconditionally, even when validation fails, so a TeleportRole CR
+	// created later still re-enqueues this CR.
+	roleNames := r.roleNames(k8sResource)
+	r.roleIndex.set(kclient.ObjectKeyFromObject(obj), roleNames)
+	missingRoles, roleLookupErr := validateRoleNames(ctx, r.Client, r.roleExists, obj.GetNamespace(), roleNames)
+	meta.SetStatusCondition(k8sResource.StatusConditions(), getRolesResolvedCondition(missingRoles, roleLookupErr))
+
+	var rolesErr error
+	switch {
+	case roleLookupErr != nil:
+		rolesErr = roleLookupErr
+	case len(missingRoles) > 0:
+		rolesErr = trace.BadParameter("roles not found: %s", strings.Join(missingRoles, ", "))
+	}
+	if rolesErr != nil {
+		errs = append(errs, rolesErr)
+	}
+
+	// Validate the CR's groupResolver block, if any, the same way: independently of the
+	// phases above, contributing its own condition and skipping the sync on failure.
+	groupResolverErr := r.validateGroupResolver(ctx, r.Client, obj)
+	meta.SetStatusCondition(k8sResource.StatusConditions(), getGroupResolverConfiguredCondition(groupResolverErr))
+	if groupResolverErr != nil {
+		errs = append(errs, groupResolverErr)
 	}
 
-	teleportResource.SetOrigin(types.OriginKubernetes)
+	ownershipCondition, ownershipErr := checkOwnership(existingResource, exists)
+	meta.SetStatusCondition(k8sResource.StatusConditions(), ownershipCondition)
+	if ownershipErr != nil {
+		errs = append(errs, ownershipErr)
+	}
 
-	if !exists {
-		err = r.CreateTeleportResource(ctx, teleportResource)
-	} else {
-		/* TODO: handle modifier logic like CreatedBy for users,
-		we can add mutate logic, diffing could also happen here */
-		err = r.UpdateTeleportResource(ctx, teleportResource)
+	var syncErr error
+	switch {
+	case secretErr != nil:
+		syncErr = secretErr
+	case rolesErr != nil:
+		syncErr = rolesErr
+	case groupResolverErr != nil:
+		syncErr = groupResolverErr
+	case ownershipErr != nil:
+		syncErr = ownershipErr
+	default:
+		teleportResource.SetOrigin(types.OriginKubernetes)
+		teleportResource = r.mutate(existingResource, teleportResource, exists)
+		if !exists {
+			syncErr = r.CreateTeleportResource(ctx, teleportResource)
+		} else {
+			syncErr = r.UpdateTeleportResource(ctx, teleportResource)
+		}
+		if syncErr != nil {
+			errs = append(errs, syncErr)
+		}
 	}
-	// If an error happens we want to put it in status.conditions before returning.
-	newReconciliationCondition := getReconciliationConditionFromError(err)
-	meta.SetStatusCondition(k8sResource.StatusConditions(), newReconciliationCondition)
-	if err != nil {
+	meta.SetStatusCondition(k8sResource.StatusConditions(), getTeleportSyncedCondition(syncErr, ownershipErr != nil || secretErr != nil || rolesErr != nil || groupResolverErr != nil))
+
+	if ownershipErr == nil && syncErr == nil {
+		r.backoff.reset(name)
+		// Remember the revision Teleport assigns this write so the next sync can tell our
+		// own change apart from one made out-of-band.
+		if synced, ferr := r.GetTeleportResource(ctx, teleportResource.GetName()); ferr == nil {
+			r.drift.record(name, synced.GetRevision())
+		}
+	}
+
+	statusErr := r.Status().Update(ctx, k8sResource)
+	meta.SetStatusCondition(k8sResource.StatusConditions(), getStatusPersistedCondition(statusErr))
+	if statusErr != nil {
+		errs = append(errs, statusErr)
+		// Best effort: the write above just failed, but try once more so the
+		// StatusPersisted=False condition itself has a chance to reach the API server.
 		silentUpdateStatus(ctx, r.Client, k8sResource)
-		return trace.Wrap(err)
 	}
 
-	// We update the status conditions on exit
-	return trace.Wrap(r.Status().Update(ctx, k8sResource))
+	if len(errs) > 0 {
+		return r.handleResultAndError(name, trace.NewAggregate(errs...))
+	}
+
+	if r.syncPeriod > 0 {
+		return ctrl.Result{RequeueAfter: r.syncPeriod}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *TeleportResourceReconciler[T, K]) Delete(ctx context.Context, obj kclient.Object) (ctrl.Result, error) {
+	name := kclient.ObjectKeyFromObject(obj).String()
+	err := r.DeleteTeleportResource(ctx, obj.GetName())
+	if err != nil && !trace.IsNotFound(err) {
+		return r.handleResultAndError(name, err)
+	}
+	r.backoff.reset(name)
+	r.drift.forget(name)
+	return ctrl.Result{}, nil
+}
+
+// handleResultAndError classifies err and turns it into the ctrl.Result/error pair
+// controller-runtime expects: terminal errors are surfaced but not requeued (the spec or
+// ownership needs a human to fix it), transient errors requeue after an exponential
+// backoff capped at r.backoff.max, and in-progress errors requeue after a short fixed
+// interval.
+func (r *TeleportResourceReconciler[T, K]) handleResultAndError(name string, err error) (ctrl.Result, error) {
+	switch classifyReconcileError(err) {
+	case errClassInProgress:
+		return ctrl.Result{RequeueAfter: inProgressRequeueInterval}, nil
+	case errClassTransient:
+		return ctrl.Result{RequeueAfter: r.backoff.next(name)}, nil
+	default:
+		return ctrl.Result{}, trace.Wrap(err)
+	}
+}
+
+// reconcileErrorClass buckets a reconciliation error so callers can decide whether (and how
+// soon) to requeue.
+type reconcileErrorClass int
+
+const (
+	// errClassTerminal means retrying won't help: a human needs to fix the spec or an
+	// ownership conflict.
+	errClassTerminal reconcileErrorClass = iota
+	// errClassTransient means the Teleport auth server was unreachable or overloaded;
+	// retry after a backoff.
+	errClassTransient
+	// errClassInProgress means the resource is still propagating downstream in Teleport;
+	// retry soon without backing off.
+	errClassInProgress
+)
+
+// errorAggregate is satisfied by trace.NewAggregate's return value; duck-typed so this
+// file doesn't need to depend on the exact interface name trace exports.
+type errorAggregate interface {
+	Errors() []error
+}
+
+// classifyReconcileError classifies err, unwrapping an aggregate into the worst class
+// found among its causes: terminal beats transient beats in-progress, since a single
+// unfixable cause means the whole reconcile needs a human regardless of what else failed.
+func classifyReconcileError(err error) reconcileErrorClass {
+	if err == nil {
+		return errClassTerminal
+	}
+
+	if agg, ok := err.(errorAggregate); ok {
+		worst := errClassInProgress
+		for _, cause := range agg.Errors() {
+			switch classifyReconcileError(cause) {
+			case errClassTerminal:
+				return errClassTerminal
+			case errClassTransient:
+				worst = errClassTransient
+			}
+		}
+		return worst
+	}
+
+	switch {
+	case trace.IsConnectionProblem(err), trace.IsLimitExceeded(err):
+		return errClassTransient
+	case trace.IsCompareFailed(err):
+		return errClassInProgress
+	default:
+		return errClassTerminal
+	}
+}
+
+// aggregateMessage renders err as a single message, expanding an errorAggregate into its
+// underlying causes instead of relying on its default, often-truncated Error() string.
+func aggregateMessage(err error) string {
+	agg, ok := err.(errorAggregate)
+	if !ok {
+		return err.Error()
+	}
+
+	causes := agg.Errors()
+	messages := make([]string, 0, len(causes))
+	for _, cause := range causes {
+		messages = append(messages, cause.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// conditionSeverity mirrors the Warning/Error/Info severity levels used by
+// clusterv1.Condition, folded into the Reason of a metav1.Condition since that type has
+// no dedicated severity field.
+type conditionSeverity string
+
+const (
+	conditionSeverityError   conditionSeverity = "Error"
+	conditionSeverityWarning conditionSeverity = "Warning"
+)
+
+// getTeleportSyncedCondition turns the result of a create/update call against Teleport
+// into a status condition, tagging transient and in-progress failures distinctly from
+// terminal ones so users can tell "will retry" from "give up". skipped is true when the
+// sync was never attempted because an earlier phase (secret resolution, role validation,
+// groupResolver validation or ownership validation) failed; err is then the error of the
+// first of those that failed, so its message still surfaces the root cause.
+func getTeleportSyncedCondition(err error, skipped bool) v1.Condition {
+	if err == nil {
+		return v1.Condition{
+			Type:    ConditionTypeTeleportSynced,
+			Status:  v1.ConditionTrue,
+			Reason:  "TeleportResourceSynced",
+			Message: "Teleport resource is in sync with the Kubernetes spec",
+		}
+	}
+
+	if skipped {
+		return v1.Condition{
+			Type:    ConditionTypeTeleportSynced,
+			Status:  v1.ConditionUnknown,
+			Reason:  "SyncSkipped",
+			Message: fmt.Sprintf("Skipped syncing to Teleport: %s", aggregateMessage(err)),
+		}
+	}
+
+	class := classifyReconcileError(err)
+	reason := "TeleportResourceSyncFailed"
+	severity := conditionSeverityError
+	switch class {
+	case errClassTransient:
+		reason = "TeleportUnreachable"
+		severity = conditionSeverityWarning
+	case errClassInProgress:
+		reason = "TeleportResourceReconciling"
+		severity = conditionSeverityWarning
+	}
+
+	return v1.Condition{
+		Type:    ConditionTypeTeleportSynced,
+		Status:  v1.ConditionFalse,
+		Reason:  reason,
+		Message: fmt.Sprintf("[%s] %s", severity, aggregateMessage(err)),
+	}
 }
-func (r TeleportResourceReconciler[T, K]) Delete(ctx context.Context, obj kclient.Object) error {
-	return r.DeleteTeleportResource(ctx, obj.GetName())
+
+// getStatusPersistedCondition reports whether the CR's status subresource was
+// successfully written back to the Kubernetes API server.
+func getStatusPersistedCondition(err error) v1.Condition {
+	if err == nil {
+		return v1.Condition{
+			Type:    ConditionTypeStatusPersisted,
+			Status:  v1.ConditionTrue,
+			Reason:  "StatusPersisted",
+			Message: "Status conditions were persisted",
+		}
+	}
+
+	return v1.Condition{
+		Type:    ConditionTypeStatusPersisted,
+		Status:  v1.ConditionFalse,
+		Reason:  "StatusUpdateFailed",
+		Message: aggregateMessage(err),
+	}
+}
+
+// transientFailureBackoff hands out an exponentially increasing, capped requeue delay per
+// resource name, resetting to base after a successful reconcile.
+type transientFailureBackoff struct {
+	mu       sync.Mutex
+	base     time.Duration
+	max      time.Duration
+	failures map[string]int
+}
+
+func newTransientFailureBackoff(base, max time.Duration) transientFailureBackoff {
+	return transientFailureBackoff{base: base, max: max, failures: make(map[string]int)}
+}
+
+func (b *transientFailureBackoff) next(name string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	attempt := b.failures[name]
+	b.failures[name] = attempt + 1
+
+	delay := b.base << attempt
+	if delay <= 0 || delay > b.max {
+		delay = b.max
+	}
+	return delay
+}
+
+func (b *transientFailureBackoff) reset(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.failures, name)
 }
@@ -16,6 +16,9 @@ type resourceTestingPrimitives[T types.ResourceWithOrigin, K TeleportKubernetesR
 	createTeleportResource(context.Context, string) error
 	getTeleportResource(context.Context, string) (T, error)
 	deleteTeleportResource(context.Context, string) error
+	// modifyTeleportResource mutates the Teleport-side resource directly, simulating an
+	// out-of-band change made by an admin rather than through the Kubernetes CR.
+	modifyTeleportResource(context.Context, string) error
 	// Interacting with the Kubernetes Resource
 	createKubernetesResource(context.Context, string) error
 	deleteKubernetesResource(context.Context, string) error
@@ -23,6 +26,13 @@ type resourceTestingPrimitives[T types.ResourceWithOrigin, K TeleportKubernetesR
 	modifyKubernetesResource(context.Context, string) error
 	// Comparing both
 	compareTeleportAndKubernetesResource(T, K) bool
+	// createKubernetesResourceWithoutSecret creates the CR the same way createKubernetesResource
+	// does, but leaving whatever field Teleport manages server-side (a client secret, a signing
+	// key pair, ...) unset, so the default Mutator is exercised instead of bypassed.
+	createKubernetesResourceWithoutSecret(context.Context, string) error
+	// secretPreserved reports whether tResource still carries the server-managed secret/key
+	// material it was created with.
+	secretPreserved(T) bool
 }
 
 func testResourceCreation[T types.ResourceWithOrigin, K TeleportKubernetesResource[T]](t *testing.T, test resourceTestingPrimitives[T, K]) {
@@ -157,3 +167,76 @@ func testResourceUpdate[T types.ResourceWithOrigin, K TeleportKubernetesResource
 		return test.compareTeleportAndKubernetesResource(tResource, kResource)
 	})
 }
+
+// testResourceOutOfBandDrift exercises periodic re-sync: a Teleport-side change made
+// without touching the Kubernetes CR should still be reverted once the SyncPeriod
+// requeue fires, since nothing in Kubernetes changed to trigger a watch event.
+func testResourceOutOfBandDrift[T types.ResourceWithOrigin, K TeleportKubernetesResource[T]](t *testing.T, test resourceTestingPrimitives[T, K]) {
+	ctx := context.Background()
+	setup := setupTestEnv(t)
+	test.init(setup)
+	resourceName := validRandomResourceName("resource-")
+
+	err := test.setupTeleportFixtures(ctx)
+	require.NoError(t, err)
+
+	err = test.createKubernetesResource(ctx, resourceName)
+	require.NoError(t, err)
+
+	fastEventually(t, func() bool {
+		_, err := test.getTeleportResource(ctx, resourceName)
+		return err == nil
+	})
+
+	// Simulate an admin editing the Teleport resource directly, outside of Kubernetes.
+	err = test.modifyTeleportResource(ctx, resourceName)
+	require.NoError(t, err)
+
+	// The periodic sync should notice the drift and put the Teleport resource back in
+	// sync with the (unchanged) Kubernetes spec, without anyone touching the CR.
+	fastEventually(t, func() bool {
+		tResource, err := test.getTeleportResource(ctx, resourceName)
+		if err != nil {
+			return false
+		}
+
+		kResource, err := test.getKubernetesResource(ctx, resourceName)
+		if err != nil {
+			return false
+		}
+
+		return test.compareTeleportAndKubernetesResource(tResource, kResource)
+	})
+}
+
+// testResourceMutator exercises the default Mutator for a resource kind: a secret/key that
+// was only ever set directly against Teleport must survive a Kubernetes-originated create
+// that leaves that field unset, instead of being blanked out by the CR's spec.
+func testResourceMutator[T types.ResourceWithOrigin, K TeleportKubernetesResource[T]](t *testing.T, test resourceTestingPrimitives[T, K]) {
+	ctx := context.Background()
+	setup := setupTestEnv(t)
+	test.init(setup)
+	resourceName := validRandomResourceName("resource-")
+
+	err := test.setupTeleportFixtures(ctx)
+	require.NoError(t, err)
+
+	// The Teleport resource already exists, created out-of-band with its secret/key set.
+	err = test.createTeleportResource(ctx, resourceName)
+	require.NoError(t, err)
+	before, err := test.getTeleportResource(ctx, resourceName)
+	require.NoError(t, err)
+
+	// The CR takes ownership of it without carrying that secret/key itself.
+	err = test.createKubernetesResourceWithoutSecret(ctx, resourceName)
+	require.NoError(t, err)
+
+	fastEventually(t, func() bool {
+		tResource, err := test.getTeleportResource(ctx, resourceName)
+		if err != nil {
+			return false
+		}
+		// Only a new revision shows the operator's own write has happened.
+		return tResource.GetRevision() != before.GetRevision() && test.secretPreserved(tResource)
+	})
+}